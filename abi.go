@@ -0,0 +1,104 @@
+package forceexport
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// Go functions are called with the register-based ABIInternal on most
+// architectures, while assembly functions use the stack-based ABI0. A func
+// value always calls its code pointer with ABIInternal, so the code pointer
+// must be an ABIInternal entry point. When a function is referenced across
+// the ABI boundary, the toolchain generates a wrapper with the same name:
+// an ABI0 wrapper for a Go function called from assembly, or an ABIInternal
+// wrapper for an assembly function called from Go. A name can thus map to two
+// entries in the function table, and lookups must pick the right one.
+
+// funcIDNormal is the funcID of ordinary functions; wrappers and special
+// runtime functions have other IDs, whose values change between versions.
+const funcIDNormal = 0
+
+// registerABI reports whether ABIInternal passes arguments in registers on
+// this architecture and Go version. It is measured rather than looked up: the
+// stack argument area of abiProbe then only holds the spill slot of its
+// argument, not its result.
+var registerABI = rawFunc(runtime.FuncForPC(reflect.ValueOf(abiProbe).Pointer())).args == int32(unsafe.Sizeof(0))
+
+//go:noinline
+func abiProbe(x int) int {
+	return x
+}
+
+// rawFunc returns the function table entry underlying f. Every *runtime.Func
+// handed out by the module wrappers points at one.
+func rawFunc(f *runtime.Func) *_func {
+	return (*_func)(unsafe.Pointer(f))
+}
+
+// funcMatch keeps the best entry seen for one name while walking the function
+// table: a normal Go function beats a wrapper, which beats an assembly
+// function.
+type funcMatch struct {
+	f     *runtime.Func
+	entry uintptr
+	rank  int // 0: not found, 1: assembly, 2: wrapper or special, 3: normal
+}
+
+// add considers f, which must have the name being looked up, and reports
+// whether the match is final, i.e. no better entry can turn up.
+func (me *funcMatch) add(f *runtime.Func) bool {
+	fn := rawFunc(f)
+	rank := 3
+	if FuncFlag(fn.flag)&FuncFlagAsm != 0 {
+		rank = 1
+	} else if fn.funcID != funcIDNormal {
+		rank = 2
+	}
+	if rank > me.rank {
+		me.f, me.entry, me.rank = f, f.Entry(), rank
+	}
+	return me.rank == 3
+}
+
+// result returns the entry to call through a func value, or an error if the
+// name only denotes an ABI0 assembly function. An assembly function whose ABI
+// cannot be determined is an error too, unless config.AssumeABIInternal is set.
+func (me *funcMatch) result(name string, config *Config) (uintptr, error) {
+	if me.rank == 0 {
+		return 0, fmt.Errorf("Invalid function name: %s", name)
+	}
+	if me.rank == 1 && registerABI {
+		abi0, err := isABI0(name, me.entry)
+		if err != nil && !config.AssumeABIInternal {
+			return 0, fmt.Errorf("cannot tell whether the assembly function %s uses ABI0 (set Config.AssumeABIInternal to call it anyway): %v", name, err)
+		}
+		if abi0 {
+			return 0, fmt.Errorf("%s is an ABI0 assembly function without an ABIInternal wrapper; it cannot be called through a Go func value", name)
+		}
+	}
+	return me.entry, nil
+}
+
+// isABI0 reports whether the assembly function at entry uses ABI0. Assembly in
+// the runtime and a few low-level packages may be declared ABIInternal, and the
+// function table does not tell the two apart, but the symbol table does: ABI0
+// symbols carry a ".abi0" suffix. Without a symbol table (stripped binaries,
+// including go test binaries) or for functions of plugins, the ABI cannot be
+// determined and an error is returned.
+func isABI0(name string, entry uintptr) (bool, error) {
+	modules, err := Modules()
+	if err != nil {
+		return false, err
+	}
+	if entry < modules[0].Text || entry >= modules[0].EText {
+		return false, fmt.Errorf("%s is not in the main executable", name)
+	}
+	exeSymbols.once.Do(loadExeSymbols)
+	if exeSymbols.err != nil {
+		return false, exeSymbols.err
+	}
+	pc, err := findExeSymbol(name + ".abi0")
+	return err == nil && pc == entry, nil
+}
@@ -0,0 +1,55 @@
+//go:build go1.18
+// +build go1.18
+
+package forceexport
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// The ABI of an assembly function is only known from the symbol table, which
+// go test strips from test binaries, so it is checked by a separately built
+// program. DWARF is left out because the linknamed moduledata variables break
+// its generation before Go 1.21.
+func TestABI0(t *testing.T) {
+	if !registerABI {
+		t.Skipf("%s has no register ABI", runtime.GOARCH)
+	}
+	for _, c := range []struct{ ldflags, arg string }{
+		{"-w", ""},
+		{"-s -w", "stripped"},
+	} {
+		exe := filepath.Join(t.TempDir(), "abi")
+		goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+		cmd := exec.Command(goCmd, "build", "-ldflags="+c.ldflags, "-o", exe, "./testdata/abi")
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatal(err)
+		}
+		out, err := exec.Command(exe, c.arg).CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "ok" {
+			t.Errorf("%s %s: %v\n%s", exe, c.arg, err, out)
+		}
+	}
+}
+
+func TestABI0Wrapper(t *testing.T) {
+	if !registerABI {
+		t.Skipf("%s has no register ABI", runtime.GOARCH)
+	}
+	// moveMakeFuncArgPtrs is a Go function called from assembly, so the
+	// function table also holds an ABI0 wrapper with the same name.
+	name := "reflect.moveMakeFuncArgPtrs"
+	codePtr, err := FindFuncWithName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := rawFunc(runtime.FuncForPC(codePtr)); f.funcID != funcIDNormal || FuncFlag(f.flag)&FuncFlagAsm != 0 {
+		t.Errorf("Expected the Go body of %s, got funcID %d flag %d.", name, f.funcID, f.flag)
+	}
+}
@@ -0,0 +1,80 @@
+package forceexport
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+var errNoBuildID = errors.New("no Go build ID found")
+
+// executableBuildID returns the Go build ID of the running executable.
+func executableBuildID() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return readBuildID(exe)
+}
+
+// readBuildID returns the Go build ID of the binary at path, as printed by
+// go tool buildid. ELF binaries carry it in a .note.go.buildid note; in other
+// formats the linker puts it at the start of the text section, which comes
+// early in the file.
+func readBuildID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if ef, err := elf.NewFile(f); err == nil {
+		if s := ef.Section(".note.go.buildid"); s != nil {
+			data, err := s.Data()
+			if err != nil {
+				return "", err
+			}
+			return parseBuildIDNote(data, ef.ByteOrder)
+		}
+	}
+
+	head := make([]byte, 32<<10)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return findBuildIDMarker(head[:n])
+}
+
+// parseBuildIDNote decodes an ELF note of type 4 named "Go".
+func parseBuildIDNote(data []byte, order binary.ByteOrder) (string, error) {
+	const noteTypeGoBuildID = 4
+	if len(data) < 16 {
+		return "", errNoBuildID
+	}
+	namesz, descsz, typ := order.Uint32(data), order.Uint32(data[4:]), order.Uint32(data[8:])
+	if namesz != 4 || typ != noteTypeGoBuildID || string(data[12:15]) != "Go\x00" || uint64(16)+uint64(descsz) > uint64(len(data)) {
+		return "", errNoBuildID
+	}
+	return string(data[16 : 16+descsz]), nil
+}
+
+// findBuildIDMarker finds the build ID string the linker emits as
+//
+//	\xff Go build ID: "<id>"\n \xff
+func findBuildIDMarker(data []byte) (string, error) {
+	prefix := []byte("\xff Go build ID: \"")
+	i := bytes.Index(data, prefix)
+	if i < 0 {
+		return "", errNoBuildID
+	}
+	data = data[i+len(prefix):]
+	j := bytes.Index(data, []byte("\"\n \xff"))
+	if j < 0 {
+		return "", errNoBuildID
+	}
+	return string(data[:j]), nil
+}
@@ -0,0 +1,38 @@
+package forceexport
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExecutableBuildID(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "tool", "buildid", exe).Output()
+	if err != nil {
+		t.Skipf("go tool buildid: %v", err)
+	}
+	id, err := executableBuildID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSpace(string(out)); id != want {
+		t.Errorf("Expected build ID %q, got %q.", want, id)
+	}
+}
+
+func TestFindBuildIDMarker(t *testing.T) {
+	id, err := findBuildIDMarker([]byte("\x00\x01\xff Go build ID: \"abc/def\"\n \xff\x00"))
+	if err != nil || id != "abc/def" {
+		t.Errorf("Expected abc/def, got %q, %v.", id, err)
+	}
+	if _, err := findBuildIDMarker([]byte("\xff Go build ID: \"abc")); err == nil {
+		t.Error("Expected an error for a truncated marker.")
+	}
+}
@@ -0,0 +1,67 @@
+//go:build go1.16 && linux && cgo
+// +build go1.16,linux,cgo
+
+package forceexport
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestBuildModes builds testdata/buildmode as a PIE executable and as a C
+// shared library and archive called from testdata/harness.c, and runs each
+// with the default strategies and with the memory scan alone.
+func TestBuildModes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds several binaries")
+	}
+	goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+	out, err := exec.Command(goCmd, "env", "CC").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := exec.LookPath(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Skipf("no C compiler: %v", err)
+	}
+	dir := t.TempDir()
+	harness, err := filepath.Abs("testdata/harness.c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(name string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(name, args...)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("%s %s: %v", name, strings.Join(args, " "), err)
+		}
+	}
+	build := func(mode, out string) {
+		t.Helper()
+		// DWARF is left out because the linknamed moduledata variables
+		// break its generation before Go 1.21.
+		run(goCmd, "build", "-buildmode="+mode, "-ldflags=-w", "-o", filepath.Join(dir, out), "./testdata/buildmode")
+	}
+
+	build("pie", "pie")
+	build("c-shared", "libcheck.so")
+	build("c-archive", "libcheck.a")
+	run(cc, "-o", filepath.Join(dir, "c-shared"), harness, "-L"+dir, "-lcheck", "-Wl,-rpath,"+dir)
+	run(cc, "-o", filepath.Join(dir, "c-archive"), harness, filepath.Join(dir, "libcheck.a"), "-lpthread")
+
+	for _, exe := range []string{"pie", "c-shared", "c-archive"} {
+		for _, strategy := range []string{"", "scan"} {
+			cmd := exec.Command(filepath.Join(dir, exe))
+			cmd.Env = append(os.Environ(), "FORCEEXPORT_STRATEGY="+strategy)
+			out, err := cmd.CombinedOutput()
+			if err != nil || strings.TrimSpace(string(out)) != "ok" {
+				t.Errorf("%s with strategy %q: %v\n%s", exe, strategy, err, out)
+			}
+		}
+	}
+}
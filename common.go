@@ -2,7 +2,7 @@ package forceexport
 
 import (
 	"runtime"
-	_ "unsafe"
+	"unsafe"
 )
 
 type (
@@ -19,7 +19,13 @@ type (
 	moduleWrapper interface {
 		GetFtab() []functab
 		GetFunc(ftab functab) *runtime.Func
+		GetEntry(ftab functab) uintptr
+		FindFunc(pc uintptr) int
 		GetNext() moduleWrapper
+		GetInfo() Module
+		GetPCTab() []byte
+		GetFuncFile(fn *_func, fileno int32) string
+		GetFuncData(fn *_func, i uint8) unsafe.Pointer
 	}
 )
 
@@ -0,0 +1,159 @@
+package forceexport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/szmcdull/go-forceexport/internal/pclntab"
+)
+
+// Defaults of the Config fields.
+const (
+	DefaultScanRange      = 0x2000000        // 32MB on each side of the code
+	DefaultMinScanAddr    = 0x400000         // below the usual text start
+	DefaultPCHeaderBefore = 200000           // pcHeader distance before the code
+	DefaultPCHeaderAfter  = 0x40000000       // pcHeader distance after the code
+	DefaultMaxFuncs       = pclntab.MaxFuncs // nfunc of a plausible pcHeader
+)
+
+// Strategy selects a way of finding runtime.firstmoduledata.
+type Strategy uint
+
+const (
+	// StrategyLinkname uses runtime.firstmoduledata linked with go:linkname,
+	// which needs -tags=checklinkname_off -ldflags=-checklinkname=0.
+	StrategyLinkname Strategy = 1 << iota
+	// StrategyAsm reads the module data pointer the runtime exports to
+	// assembly (amd64 and arm64 only, disabled by -tags=forceexport_noasm).
+	StrategyAsm
+	// StrategyCache uses the offset recorded in Config.CacheDir.
+	StrategyCache
+	// StrategyScan searches the memory around the code.
+	StrategyScan
+
+	StrategyAll = StrategyLinkname | StrategyAsm | StrategyCache | StrategyScan
+)
+
+// Config controls how the module data is discovered where it cannot be
+// linked, i.e. on Go 1.23 and later without -checklinkname=0, and how
+// functions are picked. Older Go versions link the module data and ignore the
+// discovery fields. Zero fields take their defaults.
+type Config struct {
+	// ScanRange is how far the scan looks from the code in each direction.
+	ScanRange uintptr
+	// MinScanAddr is the lowest address the scan visits.
+	MinScanAddr uintptr
+	// PCHeaderBefore and PCHeaderAfter bound the distance between the code
+	// and the pcHeader of a candidate moduledata.
+	PCHeaderBefore, PCHeaderAfter uintptr
+	// MaxFuncs is the largest function count accepted in a pcHeader.
+	MaxFuncs uint32
+
+	// Context aborts the scan when it is done. A scan aborted this way is
+	// not remembered: the next lookup scans again.
+	Context context.Context
+	// Timeout aborts the scan after the given duration, if positive.
+	Timeout time.Duration
+
+	// CacheDir enables caching where the scan found the module data, e.g.
+	// in a directory under os.UserCacheDir, so that later starts of the
+	// same binary need not scan. Empty disables the cache.
+	CacheDir string
+
+	// Strategies selects the ways of finding the module data, tried in the
+	// order linkname, asm, cache, scan. Zero means StrategyAll.
+	Strategies Strategy
+
+	// Logf, if set, receives progress messages of the discovery.
+	Logf func(format string, args ...interface{})
+
+	// AssumeABIInternal makes lookups accept assembly functions whose ABI
+	// cannot be read from the symbol table, e.g. in stripped binaries or
+	// plugins, as if they were ABIInternal. Calling one that is ABI0 through
+	// a func value corrupts its arguments. It only matters on architectures
+	// with the register ABI.
+	AssumeABIInternal bool
+}
+
+// DefaultConfig returns the Config used unless SetConfig is called.
+func DefaultConfig() Config {
+	return Config{
+		ScanRange:      DefaultScanRange,
+		MinScanAddr:    DefaultMinScanAddr,
+		PCHeaderBefore: DefaultPCHeaderBefore,
+		PCHeaderAfter:  DefaultPCHeaderAfter,
+		MaxFuncs:       DefaultMaxFuncs,
+		Strategies:     StrategyAll,
+	}
+}
+
+var globalConfig struct {
+	sync.Mutex
+	config Config
+}
+
+// SetConfig sets the Config of lookups that are not given one, such as
+// GetFunc. It should be called once, before the first lookup: the module data
+// is only discovered once, so a later Config only matters if that failed.
+func SetConfig(config Config) {
+	globalConfig.Lock()
+	globalConfig.config = config
+	globalConfig.Unlock()
+	resetModuleDataDiscovery()
+}
+
+// SetModuleData tells forceexport where runtime.firstmoduledata is, e.g. from
+// the application's own go:linkname or symbol table, so that it is never
+// searched for. The address is checked to hold a plausible moduledata first.
+// Before Go 1.23 the module data is linked and addr must be its address.
+func SetModuleData(addr uintptr) error {
+	return setModuleData(addr)
+}
+
+// currentConfig returns the Config set with SetConfig with defaults filled in.
+func currentConfig() Config {
+	globalConfig.Lock()
+	defer globalConfig.Unlock()
+	return globalConfig.config.withDefaults()
+}
+
+func (me Config) withDefaults() Config {
+	d := DefaultConfig()
+	if me.ScanRange == 0 {
+		me.ScanRange = d.ScanRange
+	}
+	if me.MinScanAddr == 0 {
+		me.MinScanAddr = d.MinScanAddr
+	}
+	if me.PCHeaderBefore == 0 {
+		me.PCHeaderBefore = d.PCHeaderBefore
+	}
+	if me.PCHeaderAfter == 0 {
+		me.PCHeaderAfter = d.PCHeaderAfter
+	}
+	if me.MaxFuncs == 0 {
+		me.MaxFuncs = d.MaxFuncs
+	}
+	if me.Strategies == 0 {
+		me.Strategies = d.Strategies
+	}
+	if me.Context == nil {
+		me.Context = context.Background()
+	}
+	return me
+}
+
+func (me *Config) logf(format string, args ...interface{}) {
+	if me.Logf != nil {
+		me.Logf(format, args...)
+	}
+}
+
+// WithConfig makes Resolve discover the module data with config instead of
+// the one set with SetConfig, if it has not been discovered yet. Unlike with
+// the global Config, a failed discovery is retried by every Resolve.
+func (me *Resolver) WithConfig(config Config) *Resolver {
+	me.config = &config
+	return me
+}
@@ -0,0 +1,25 @@
+//go:build !go1.23
+// +build !go1.23
+
+package forceexport
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Before Go 1.23 the module data is linked with go:linkname and there is
+// nothing to discover.
+
+func discoverModuleData(config *Config) error {
+	return nil
+}
+
+func resetModuleDataDiscovery() {}
+
+func setModuleData(addr uintptr) error {
+	if linked := uintptr(unsafe.Pointer(&Firstmoduledata)); addr != linked {
+		return fmt.Errorf("no moduledata at %#x: runtime.firstmoduledata is linked at %#x", addr, linked)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+//go:build go1.23
+// +build go1.23
+
+package forceexport
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConfigDiscovery(t *testing.T) {
+	addr := findFirstModuleData()
+	if addr == 0 {
+		t.Skip("moduledata not found")
+	}
+	defer atomic.StoreUintptr(&moduleDataAddr, addr)
+	atomic.StoreUintptr(&moduleDataAddr, 0)
+
+	var log []string
+	config := Config{
+		Strategies: StrategyScan,
+		Logf: func(format string, args ...interface{}) {
+			log = append(log, fmt.Sprintf(format, args...))
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	config.Context = ctx
+	if err := NewResolver().WithConfig(config).Resolve(); err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Errorf("Expected a cancelled scan to be aborted, got %v.", err)
+	}
+
+	config.Context = nil
+	config.ScanRange = 0x100
+	if err := NewResolver().WithConfig(config).Resolve(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a narrow scan to fail, got %v.", err)
+	}
+
+	config.ScanRange = 0
+	var addOneFunc func(x int) int
+	if err := NewResolver().WithConfig(config).Add(&addOneFunc, "github.com/szmcdull/go-forceexport.addOne").Resolve(); err != nil {
+		t.Fatal(err)
+	}
+	if got := atomic.LoadUintptr(&moduleDataAddr); got != addr {
+		t.Errorf("Expected moduledata at %#x, found %#x.", addr, got)
+	}
+	if len(log) == 0 || !strings.Contains(log[len(log)-1], "moduledata at") {
+		t.Errorf("Expected progress messages, got %q.", log)
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	c := Config{MaxFuncs: 10}.withDefaults()
+	if c.MaxFuncs != 10 || c.ScanRange != DefaultScanRange || c.Strategies != StrategyAll || c.Context == nil {
+		t.Errorf("Unexpected config %+v.", c)
+	}
+}
+
+func TestSetModuleData(t *testing.T) {
+	addr := findFirstModuleData()
+	if addr == 0 {
+		t.Skip("moduledata not found")
+	}
+	defer atomic.StoreUintptr(&moduleDataAddr, addr)
+	atomic.StoreUintptr(&moduleDataAddr, 0)
+
+	if err := SetModuleData(addr + ptrSize); err == nil {
+		t.Error("Expected a wrong address to be rejected.")
+	}
+	if err := SetModuleData(addr); err != nil {
+		t.Fatal(err)
+	}
+	// No strategy is left, so the lookup has to use the injected address.
+	SetConfig(Config{Strategies: StrategyLinkname})
+	defer SetConfig(Config{})
+	var addOneFunc func(x int) int
+	if err := GetFunc(&addOneFunc, "github.com/szmcdull/go-forceexport.addOne"); err != nil || addOneFunc(1) != 2 {
+		t.Errorf("Expected addOne to be found with the injected moduledata, got %v.", err)
+	}
+}
+
+func TestAsmModuleData(t *testing.T) {
+	addr := findFirstModuleData()
+	if addr == 0 {
+		t.Skip("moduledata not found")
+	}
+	if asmModuleData() == 0 {
+		t.Skipf("no assembly accessor on %s", runtime.GOARCH)
+	}
+	defer atomic.StoreUintptr(&moduleDataAddr, addr)
+	atomic.StoreUintptr(&moduleDataAddr, 0)
+
+	got, err := discoverFirstModuleData(Config{Strategies: StrategyAsm}.withDefaults(), false)
+	if err != nil || got != addr {
+		t.Errorf("Expected moduledata at %#x from assembly, got %#x, %v.", addr, got, err)
+	}
+}
+
+func TestIsValidPCHeader(t *testing.T) {
+	// The pcHeader of a Go 1.20+ binary for each architecture starts with the
+	// magic, two pad bytes, minLC, ptrSize and nfunc.
+	for _, c := range []struct {
+		goarch       string
+		order        binary.ByteOrder
+		minLC, psize byte
+	}{
+		{"386", binary.LittleEndian, 1, 4},
+		{"amd64", binary.LittleEndian, 1, 8},
+		{"arm", binary.LittleEndian, 4, 4},
+		{"arm64", binary.LittleEndian, 4, 8},
+		{"mips", binary.BigEndian, 4, 4},
+		{"ppc64", binary.BigEndian, 4, 8},
+		{"ppc64le", binary.LittleEndian, 4, 8},
+		{"s390x", binary.BigEndian, 2, 8},
+	} {
+		header := make([]byte, 16)
+		c.order.PutUint32(header, 0xfffffff1)
+		header[6], header[7] = c.minLC, c.psize
+		setNfunc := func(n uint64) {
+			if c.psize == 4 {
+				c.order.PutUint32(header[8:], uint32(n))
+			} else {
+				c.order.PutUint64(header[8:], n)
+			}
+		}
+		setNfunc(100)
+		config := DefaultConfig()
+		if got, want := isValidPCHeader(header, &config), c.goarch == runtime.GOARCH; got != want {
+			t.Errorf("Expected a %s header to be valid %v on %s, got %v.", c.goarch, want, runtime.GOARCH, got)
+		}
+		if c.goarch == runtime.GOARCH {
+			setNfunc(0)
+			if isValidPCHeader(header, &config) {
+				t.Error("Expected a header without functions to be invalid.")
+			}
+			setNfunc(uint64(config.MaxFuncs) + 1)
+			if isValidPCHeader(header, &config) {
+				t.Error("Expected a header with too many functions to be invalid.")
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package forceexport
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// The linker builds findfunctab to map a pc to its function without a binary
+// search over the function table: one findfuncbucket per pcbucketsize bytes
+// of text, split into subbuckets, each recording the index of the first
+// function that overlaps it. Starting there, only a few entries remain to be
+// checked.
+const (
+	minfunc      = 16            // minimum function size
+	pcbucketsize = 256 * minfunc // size of bucket in the pc->func lookup table
+	nsub         = len(findfuncbucket{}.subbuckets)
+)
+
+type findfuncbucket struct {
+	idx        uint32
+	subbuckets [16]byte
+}
+
+// findFuncBucket returns the function table index recorded for the text
+// offset x (from minpc) in findfunctab.
+func findFuncBucket(findfunctab uintptr, x uintptr) int {
+	b := x / pcbucketsize
+	i := x % pcbucketsize / (pcbucketsize / uintptr(nsub))
+	ffb := (*findfuncbucket)(unsafe.Pointer(findfunctab + b*unsafe.Sizeof(findfuncbucket{})))
+	return int(ffb.idx) + int(ffb.subbuckets[i])
+}
+
+// findFuncForPC returns the module whose function table holds the function
+// containing pc, and the function's index there.
+func findFuncForPC(pc uintptr) (moduleWrapper, int) {
+	for w := getModuleWrapper(); w != nil; w = w.GetNext() {
+		if i := w.FindFunc(pc); i >= 0 {
+			return w, i
+		}
+	}
+	return nil, -1
+}
+
+// FindFuncForPC returns the function whose code contains pc, or nil. It uses
+// the runtime's findfunctab like runtime.FuncForPC, but for pcs in inlined
+// code it returns the function they were inlined into, and it never allocates.
+func FindFuncForPC(pc uintptr) *runtime.Func {
+	module, i := findFuncForPC(pc)
+	if module == nil {
+		return nil
+	}
+	return module.GetFunc(module.GetFtab()[i])
+}
+
+// IsFuncEntry reports whether pc is the entry of a function, i.e. a code
+// pointer that can be passed to CreateFuncForCodePtr.
+func IsFuncEntry(pc uintptr) bool {
+	module, i := findFuncForPC(pc)
+	return module != nil && module.GetEntry(module.GetFtab()[i]) == pc
+}
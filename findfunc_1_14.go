@@ -0,0 +1,34 @@
+//go:build !go1.18
+// +build !go1.18
+
+package forceexport
+
+// findFuncIndex implements FindFunc for function tables holding absolute
+// entry pcs, following runtime.findfunc.
+func findFuncIndex(ftab []functab, findfunctab, minpc, maxpc, pc uintptr) int {
+	if pc < minpc || pc >= maxpc || findfunctab == 0 || len(ftab) < 2 {
+		return -1
+	}
+	idx := findFuncBucket(findfunctab, pc-minpc)
+	// With several text sections the linker may put jump tables between
+	// them, so the bucket can point past the function; search backward then.
+	if idx >= len(ftab) {
+		idx = len(ftab) - 1
+	}
+	if pc < ftab[idx].entry {
+		for idx > 0 && ftab[idx].entry > pc {
+			idx--
+		}
+		if ftab[idx].entry > pc {
+			return -1
+		}
+		return idx
+	}
+	for idx+1 < len(ftab) && ftab[idx+1].entry <= pc {
+		idx++
+	}
+	if idx+1 >= len(ftab) {
+		return -1
+	}
+	return idx
+}
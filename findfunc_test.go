@@ -0,0 +1,48 @@
+package forceexport
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFindFuncForPC(t *testing.T) {
+	module := getModuleWrapper()
+	if module == nil {
+		t.Skip("moduledata not found")
+	}
+	ftab := module.GetFtab()
+	for i := 0; i < len(ftab)-1; i++ {
+		f := module.GetFunc(ftab[i])
+		entry, next := module.GetEntry(ftab[i]), module.GetEntry(ftab[i+1])
+		for _, pc := range []uintptr{entry, (entry + next) / 2, next - 1} {
+			if got := FindFuncForPC(pc); got != f {
+				t.Fatalf("Expected %#x to be in %s, got %v.", pc, f.Name(), got)
+			}
+		}
+		if !IsFuncEntry(entry) || (next-entry > 1 && IsFuncEntry(entry+1)) {
+			t.Fatalf("Unexpected entries of %s at %#x.", f.Name(), entry)
+		}
+	}
+	if FindFuncForPC(0) != nil || IsFuncEntry(module.GetEntry(ftab[len(ftab)-1])) {
+		t.Error("Expected pcs outside the text to have no function.")
+	}
+}
+
+var benchPC = reflect.ValueOf(addOne).Pointer() + 1
+
+func BenchmarkFindFuncForPC(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if FindFuncForPC(benchPC) == nil {
+			b.Fatal("no function")
+		}
+	}
+}
+
+func BenchmarkRuntimeFuncForPC(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if runtime.FuncForPC(benchPC) == nil {
+			b.Fatal("no function")
+		}
+	}
+}
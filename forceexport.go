@@ -3,23 +3,41 @@ package forceexport
 import (
 	"fmt"
 	"reflect"
+	"runtime"
 	"strings"
 	"unsafe"
+
+	"github.com/szmcdull/go-forceexport/internal/symname"
 )
 
 // GetFunc gets the function defined by the given fully-qualified name. The
 // outFuncPtr parameter should be a pointer to a function with the appropriate
 // type (e.g. the address of a local variable), and is set to a new function
 // value that calls the specified function. If the specified function does not
-// exist, outFuncPtr is not set and an error is returned.
-func GetFunc(outFuncPtr interface{}, name string) error {
-	if strings.HasPrefix(name, `go.`) && !strings.Contains(name, `/`) {
-		name = strings.Replace(name, `go.`, `go%2e`, 1)
+// exist, outFuncPtr is not set and an error is returned. Options such as
+// Verify and Guarded add checks.
+func GetFunc(outFuncPtr interface{}, name string, opts ...Option) error {
+	var o getFuncOptions
+	for _, opt := range opts {
+		opt(&o)
 	}
-	codePtr, err := FindFuncWithName(name)
+	codePtr, err := FindFuncWithName(symname.Mangle(name))
 	if err != nil {
 		return err
 	}
+	if o.verify {
+		if !IsFuncEntry(codePtr) {
+			return fmt.Errorf("Invalid function name: %s", name)
+		}
+		info := newFuncInfo(FindFuncForPC(codePtr))
+		if err := verifyArgs(&info, reflect.TypeOf(outFuncPtr).Elem()); err != nil {
+			return err
+		}
+	}
+	if o.guarded {
+		guard(outFuncPtr, codePtr)
+		return nil
+	}
 	CreateFuncForCodePtr(outFuncPtr, codePtr)
 	return nil
 }
@@ -52,47 +70,166 @@ func CreateFuncForCodePtr(outFuncPtr interface{}, codePtr uintptr) {
 	outFuncVal.Set(newFuncVal)
 }
 
-type ()
-
 // FindFuncWithName searches through the moduledata table created by the linker
 // and returns the function's code pointer. If the function was not found, it
 // returns an error. Since the data structures here are not exported, we copy
 // them below (and they need to stay in sync or else things will fail
 // catastrophically).
+//
+// If the name denotes both a function and its ABI wrapper, the entry that
+// can be called through a Go func value is returned; an assembly function
+// that is only reachable with ABI0 yields an error.
 func FindFuncWithName(name string) (uintptr, error) {
+	var match funcMatch
+	err := forEachFunc(func(f *runtime.Func) bool {
+		if f.Name() == name {
+			return !match.add(f)
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	config := currentConfig()
+	return match.result(name, &config)
+}
+
+// forEachFunc calls fn for every function of every module, in moduledata
+// order, until fn returns false.
+func forEachFunc(fn func(f *runtime.Func) bool) error {
+	if err := discoverModuleData(nil); err != nil {
+		return err
+	}
 	module := getModuleWrapper()
 	if module == nil {
-		return 0, fmt.Errorf("moduledata not found!")
+		return fmt.Errorf("moduledata not found!")
 	}
 
-	for {
-		ftabs := module.GetFtab()
-		l := len(ftabs)
-		for i, ftab := range ftabs {
-			if i == l-1 {
-				break
-			}
-			f := module.GetFunc(ftab)
-			if f == nil {
-				continue
-			}
-			n := f.Name()
-			//fmt.Println(n)
-			// if n == `main.init.0` || n == `main.main` {
-			// 	time.Now()
-			// }
-			if n == name {
-				return f.Entry(), nil
-			}
+	for ; module != nil; module = module.GetNext() {
+		if !forEachFuncInModule(module, fn) {
+			break
 		}
-		module = module.GetNext()
-		//println(module)
-		if module == nil {
+	}
+	return nil
+}
+
+// forEachFuncInModule calls fn for every function of a single module until
+// fn returns false, and reports whether the iteration ran to the end.
+func forEachFuncInModule(module moduleWrapper, fn func(f *runtime.Func) bool) bool {
+	ftabs := module.GetFtab()
+	l := len(ftabs)
+	for i, ftab := range ftabs {
+		// The last entry is a sentinel marking the end of the text.
+		if i == l-1 {
 			break
 		}
+		f := module.GetFunc(ftab)
+		if f == nil {
+			continue
+		}
+		if !fn(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// ResolveAll looks up every function named by the keys of funcs and sets the
+// function pointed to by the corresponding value, like GetFunc, but with a
+// single pass over the function table. Functions that are found are set even
+// if others are missing; the returned error lists every missing name.
+func ResolveAll(funcs map[string]interface{}) error {
+	r := NewResolver()
+	for name, outFuncPtr := range funcs {
+		r.Add(outFuncPtr, name)
+	}
+	return r.Resolve()
+}
+
+// Resolver collects functions to look up and resolves them together with a
+// single pass over the function table, which is much cheaper than calling
+// GetFunc for each of them when there are many.
+//
+//	err := forceexport.NewResolver().
+//		Add(&timeNow, "time.now").
+//		Add(&nanotime, "runtime.nanotime").
+//		Resolve()
+type Resolver struct {
+	requests map[string][]interface{} // symbol name -> output pointers
+	names    []string                 // names in the order they were added
+	config   *Config                  // set by WithConfig
+}
+
+// NewResolver returns an empty Resolver.
+func NewResolver() *Resolver {
+	return &Resolver{requests: make(map[string][]interface{})}
+}
+
+// Add registers outFuncPtr to be set to the function with the given name, as
+// GetFunc would. It returns the Resolver so calls can be chained.
+func (me *Resolver) Add(outFuncPtr interface{}, name string) *Resolver {
+	symbol := symname.Mangle(name)
+	if _, ok := me.requests[symbol]; !ok {
+		me.names = append(me.names, name)
+	}
+	me.requests[symbol] = append(me.requests[symbol], outFuncPtr)
+	return me
+}
+
+// Resolve performs the lookup and sets every function that was found. If
+// some names were not found, their outputs are left untouched and the error
+// lists all of them. Names of ABI0-only assembly functions count as missing.
+func (me *Resolver) Resolve() error {
+	if me.config != nil {
+		if err := discoverModuleData(me.config); err != nil {
+			return err
+		}
+	}
+	matches := make(map[string]*funcMatch, len(me.requests))
+	final := 0
+	err := forEachFunc(func(f *runtime.Func) bool {
+		n := f.Name()
+		if _, ok := me.requests[n]; ok {
+			match := matches[n]
+			if match == nil {
+				match = &funcMatch{}
+				matches[n] = match
+			}
+			if match.rank < 3 && match.add(f) {
+				final++
+			}
+			return final < len(me.requests)
+		}
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
-	return 0, fmt.Errorf("Invalid function name: %s", name)
+	config := currentConfig()
+	if me.config != nil {
+		config = me.config.withDefaults()
+	}
+	var missing []string
+	for _, name := range me.names {
+		symbol := symname.Mangle(name)
+		match := matches[symbol]
+		if match == nil {
+			match = &funcMatch{}
+		}
+		codePtr, err := match.result(symbol, &config)
+		if err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		for _, outFuncPtr := range me.requests[symbol] {
+			CreateFuncForCodePtr(outFuncPtr, codePtr)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Invalid function names: %s", strings.Join(missing, ", "))
+	}
+	return nil
 }
 
 // Everything below is taken from the runtime package, and must stay in sync
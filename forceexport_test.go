@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -44,7 +45,10 @@ func TestTimeNow(t *testing.T) {
 		return
 	}
 
-	// Fallback to assembly time.now (2 return values)
+	// Fallback to assembly time.now (2 return values). It is declared
+	// ABIInternal, but the test binary has no symbol table to tell.
+	SetConfig(Config{AssumeABIInternal: true})
+	defer SetConfig(Config{})
 	var timeNowFunc func() (int64, int32)
 	err = GetFunc(&timeNowFunc, "time.now")
 	if err == nil && timeNowFunc != nil {
@@ -130,3 +134,38 @@ func TestForceExport(t *testing.T) {
 		// }
 	}
 }
+
+func TestResolveAll(t *testing.T) {
+	var addOneFunc, addOneAgain func(x int) int
+	var func1 func(*testing.T)
+	var invalidFunc func()
+	err := NewResolver().
+		Add(&addOneFunc, "github.com/szmcdull/go-forceexport.addOne").
+		Add(&addOneAgain, "github.com/szmcdull/go-forceexport.addOne").
+		Add(&func1, "github.com/szmcdull/go-forceexport.TestFunc1").
+		Add(&invalidFunc, "invalidpackage.invalidfunction").
+		Resolve()
+	if err == nil || !strings.Contains(err.Error(), "invalidpackage.invalidfunction") {
+		t.Errorf("Expected an error naming the missing function, got %v.", err)
+	}
+	if invalidFunc != nil {
+		t.Error("Expected a nil function.")
+	}
+	if addOneFunc == nil || addOneAgain == nil || func1 == nil {
+		t.Fatal("Expected the existing functions to be resolved.")
+	}
+	if addOneFunc(3) != 4 || addOneAgain(3) != 4 {
+		t.Error("Expected addOneFunc to add one to 3.")
+	}
+
+	var addOneMapped func(x int) int
+	err = ResolveAll(map[string]interface{}{
+		"github.com/szmcdull/go-forceexport.addOne": &addOneMapped,
+	})
+	if err != nil {
+		t.Error("Expected nil error.")
+	}
+	if addOneMapped == nil || addOneMapped(3) != 4 {
+		t.Error("Expected addOneMapped to add one to 3.")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build go1.16 && !go1.17
+// +build go1.16,!go1.17
+
+package forceexport
+
+// funcIDNames names the values of the runtime's funcID, indexed by value.
+var funcIDNames = [...]string{
+	"normal", "runtime_main", "goexit", "jmpdefer", "mcall", "morestack",
+	"mstart", "rt0_go", "asmcgocall", "sigpanic", "runfinq", "gcBgMarkWorker",
+	"systemstack_switch", "systemstack", "cgocallback", "gogo",
+	"externalthreadhandler", "debugCallV1", "gopanic", "panicwrap",
+	"handleAsyncEvent", "asyncPreempt", "wrapper",
+}
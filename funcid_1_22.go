@@ -0,0 +1,13 @@
+//go:build go1.22 && !go1.25
+// +build go1.22,!go1.25
+
+package forceexport
+
+// funcIDNames names the values of the runtime's funcID, indexed by value.
+var funcIDNames = [...]string{
+	"normal", "abort", "asmcgocall", "asyncPreempt", "cgocallback", "corostart",
+	"debugCallV2", "gcBgMarkWorker", "goexit", "gogo", "gopanic",
+	"handleAsyncEvent", "mcall", "morestack", "mstart", "panicwrap", "rt0_go",
+	"runfinq", "runtime_main", "sigpanic", "systemstack", "systemstack_switch",
+	"wrapper",
+}
@@ -0,0 +1,187 @@
+package forceexport
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/szmcdull/go-forceexport/internal/symname"
+)
+
+// FuncInfo describes a function found in the function table, as recorded in
+// the runtime's _func header. Fields the runtime of the Go version in use does
+// not record are left zero.
+type FuncInfo struct {
+	Name  string
+	Entry uintptr
+	File  string // source file of the function's first instruction
+	Line  int    // source line of the function's first instruction
+
+	// StartLine is the line of the func keyword or TEXT directive (Go 1.20+).
+	StartLine int
+	// Args is the size of the argument area on the stack, or
+	// ArgsSizeUnknown for assembly functions without a declared size. With
+	// the register ABI it only covers the spill slots of the arguments.
+	Args int
+	// Deferreturn is the offset of the deferreturn call from Entry, or 0 if
+	// the function has no defer.
+	Deferreturn uint32
+	FuncID      FuncID
+	Flag        FuncFlag // Go 1.17+
+	NPCData     int      // number of pcdata tables
+	NFuncData   int      // number of funcdata entries
+
+	raw *_func
+}
+
+// ArgsSizeUnknown is FuncInfo.Args for functions with unknown argument size.
+const ArgsSizeUnknown = -0x80000000
+
+// FuncID identifies the functions the runtime treats specially, such as
+// runtime.goexit, and wrappers generated by the compiler. The values change
+// between Go versions, so compare the String form rather than numbers.
+type FuncID uint8
+
+// FuncIDNormal is the FuncID of ordinary functions in every Go version.
+const FuncIDNormal FuncID = funcIDNormal
+
+// String returns the runtime's name for the ID without the funcID_ prefix,
+// e.g. "normal", "goexit" or "wrapper".
+func (me FuncID) String() string {
+	if int(me) < len(funcIDNames) {
+		return funcIDNames[me]
+	}
+	return fmt.Sprintf("FuncID(%d)", uint8(me))
+}
+
+// FuncFlag holds the runtime's flag bits of a function.
+type FuncFlag uint8
+
+const (
+	// FuncFlagTopFrame is set on functions that appear at the top of a
+	// goroutine's stack, where tracebacks stop.
+	FuncFlagTopFrame FuncFlag = 1 << iota
+	// FuncFlagSPWrite is set on functions that write the stack pointer
+	// arbitrarily.
+	FuncFlagSPWrite
+	// FuncFlagAsm is set on functions written in assembly (Go 1.18+).
+	FuncFlagAsm
+)
+
+func newFuncInfo(f *runtime.Func) FuncInfo {
+	file, line := f.FileLine(f.Entry())
+	fn := rawFunc(f)
+	return FuncInfo{
+		Name:        f.Name(),
+		Entry:       f.Entry(),
+		File:        file,
+		Line:        line,
+		StartLine:   fn.getStartLine(),
+		Args:        int(fn.args),
+		Deferreturn: fn.deferreturn,
+		FuncID:      FuncID(fn.funcID),
+		Flag:        FuncFlag(fn.flag),
+		NPCData:     int(fn.npcdata),
+		NFuncData:   int(fn.nfuncdata),
+		raw:         fn,
+	}
+}
+
+// FindFuncInfo is like FindFuncWithName, but returns everything the function
+// table records about the function instead of only its entry. Unlike
+// FindFuncWithName it also describes ABI0 assembly functions; check Flag
+// before calling one.
+func FindFuncInfo(name string) (*FuncInfo, error) {
+	name = symname.Mangle(name)
+	var match funcMatch
+	err := forEachFunc(func(f *runtime.Func) bool {
+		if f.Name() == name {
+			return !match.add(f)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if match.rank == 0 {
+		return nil, fmt.Errorf("Invalid function name: %s", name)
+	}
+	info := newFuncInfo(match.f)
+	return &info, nil
+}
+
+// Closures lists the functions the compiler generated for the body of the
+// named function: function literals (pkg.F.func1, and nested ones such as
+// pkg.F.func1.1 or pkg.F.func1.func2), wrappers for go and defer statements
+// (pkg.F.gowrap1, pkg.F.deferwrap1) and range-over-func loop bodies
+// (pkg.F-range1). The parent is named as for GetFunc; methods are written
+// pkg.T.M or pkg.(*T).M, and closures of every instantiation of a generic
+// function are included. Functions are returned in address order; the result
+// is empty if there are none or the function table cannot be found.
+func Closures(parentName string) []FuncInfo {
+	parent := symname.Mangle(parentName)
+	var closures []FuncInfo
+	forEachFunc(func(f *runtime.Func) bool {
+		if isClosureOf(f.Name(), parent) {
+			closures = append(closures, newFuncInfo(f))
+		}
+		return true
+	})
+	return closures
+}
+
+// isClosureOf reports whether name is a compiler-generated function nested in
+// the function called parent.
+func isClosureOf(name, parent string) bool {
+	if !strings.HasPrefix(name, parent) {
+		return false
+	}
+	rest := name[len(parent):]
+	// Instantiations of generic functions are named pkg.F[...].
+	if strings.HasPrefix(rest, "[") {
+		depth := 0
+		for i, c := range rest {
+			if c == '[' {
+				depth++
+			} else if c == ']' {
+				depth--
+			}
+			if depth == 0 {
+				rest = rest[i+1:]
+				break
+			}
+		}
+	}
+	if len(rest) < 2 {
+		return false
+	}
+	sep, elem := rest[0], rest[1:]
+	if i := strings.IndexAny(elem, ".-"); i >= 0 {
+		elem = elem[:i]
+	}
+	var prefixes []string
+	switch sep {
+	case '-':
+		prefixes = []string{"range"}
+	case '.':
+		prefixes = []string{"func", "gowrap", "deferwrap", ""}
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(elem, prefix) && isDigits(elem[len(prefix):]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package forceexport
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+var closureSink func() int
+
+// closureLines holds the lines of the statements of withClosures enclosing its
+// closures, recorded when it runs.
+var closureLines [2]int
+
+//go:noinline
+func withClosures(n int) int {
+	_, _, closureLines[0], _ = runtime.Caller(0)
+	done := make(chan int)
+	go func() { done <- n }()
+	closureSink = func() int { return n + 1 }
+	_, _, closureLines[1], _ = runtime.Caller(0)
+	return <-done
+}
+
+func TestClosures(t *testing.T) {
+	withClosures(1)
+	parent := "github.com/szmcdull/go-forceexport.withClosures"
+	closures := Closures(parent)
+	if len(closures) < 2 {
+		t.Fatalf("Expected at least two closures of %s, got %v.", parent, closures)
+	}
+	for _, c := range closures {
+		if !strings.HasPrefix(c.Name, parent+".") || c.Entry == 0 {
+			t.Errorf("Unexpected closure %+v.", c)
+		}
+		if !strings.HasSuffix(c.File, "funcinfo_test.go") || c.Line <= closureLines[0] || c.Line >= closureLines[1] {
+			t.Errorf("Expected %s to be located in withClosures, got %s:%d.", c.Name, c.File, c.Line)
+		}
+	}
+	if len(Closures("invalidpackage.invalidfunction")) != 0 {
+		t.Error("Expected no closures for an invalid function.")
+	}
+}
+
+func TestFindFuncInfo(t *testing.T) {
+	name := "github.com/szmcdull/go-forceexport.addOne"
+	info, err := FindFuncInfo(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codePtr, err := FindFuncWithName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name != name || info.Entry != codePtr {
+		t.Errorf("Expected %s at %#x, got %+v.", name, codePtr, info)
+	}
+	if info.FuncID != FuncIDNormal || info.FuncID.String() != "normal" || info.Flag != 0 {
+		t.Errorf("Expected a normal function, got funcID %s flag %d.", info.FuncID, info.Flag)
+	}
+	// One int argument and, with the stack ABI, one int result.
+	if info.Args != int(unsafe.Sizeof(0)) && info.Args != 2*int(unsafe.Sizeof(0)) {
+		t.Errorf("Unexpected argument size %d.", info.Args)
+	}
+	if !strings.HasSuffix(info.File, "forceexport_test.go") || info.Line == 0 || info.StartLine > info.Line {
+		t.Errorf("Unexpected position %s:%d (start line %d).", info.File, info.Line, info.StartLine)
+	}
+
+	goexit, err := FindFuncInfo("runtime.goexit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goexit.FuncID.String() != "goexit" {
+		t.Errorf("Expected the goexit funcID, got %s.", goexit.FuncID)
+	}
+
+	if _, err := FindFuncInfo("invalidpackage.invalidfunction"); err == nil {
+		t.Error("Expected an error for an invalid function.")
+	}
+}
+
+func TestIsClosureOf(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		want bool
+	}{
+		{"pkg.F.func1", true},
+		{"pkg.F.func12.1", true},
+		{"pkg.F.func1.func2", true},
+		{"pkg.F.gowrap1", true},
+		{"pkg.F.deferwrap3", true},
+		{"pkg.F-range1", true},
+		{"pkg.F.1", true},
+		{"pkg.F[map[int]string].func1", true},
+		{"pkg.F", false},
+		{"pkg.Func1", false},
+		{"pkg.F.funcs", false},
+		{"pkg.F.G", false},
+		{"pkg.FF.func1", false},
+	} {
+		if got := isClosureOf(c.name, "pkg.F"); got != c.want {
+			t.Errorf("isClosureOf(%q, pkg.F) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package forceexport
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/szmcdull/go-forceexport/internal/symname"
+)
+
+// GetGenericFunc is like GetFunc for an instantiation of a generic function,
+// e.g.
+//
+//	var index func([]int, int) int
+//	err := GetGenericFunc(&index, "slices.Index", reflect.TypeOf([]int{}), reflect.TypeOf(0))
+//
+// The compiler rarely emits a function for each instantiation. Instead it
+// compiles one body per GC shape (slices.Index[go.shape.[]int,go.shape.int])
+// that takes a hidden dictionary describing the actual type arguments as its
+// first argument. If a plain instantiation exists (it does when the program
+// takes the instantiation as a function value) it is used directly; otherwise
+// the shape function is combined with the instantiation's dictionary, which is
+// a data symbol, and the returned function passes it along transparently
+// (through reflect, so calls are slower than direct ones).
+//
+// The runtime records every instantiation under the same name (pkg.F[...]),
+// so instantiations are located through the executable's symbol table, which
+// must not be stripped. Only ELF symbol tables are read, so GetGenericFunc
+// fails on Windows, macOS and other platforms with a different executable
+// format. Only plain functions are supported, not methods of generic types.
+func GetGenericFunc(outFuncPtr interface{}, name string, typeArgs ...reflect.Type) error {
+	if len(typeArgs) == 0 {
+		return fmt.Errorf("GetGenericFunc: no type arguments for %s", name)
+	}
+	outType := reflect.TypeOf(outFuncPtr)
+	if outType == nil || outType.Kind() != reflect.Ptr || outType.Elem().Kind() != reflect.Func {
+		return fmt.Errorf("GetGenericFunc: outFuncPtr must be a pointer to a function")
+	}
+	fnType := outType.Elem()
+
+	args := make([]string, len(typeArgs))
+	shapes := make([]string, len(typeArgs))
+	for i, t := range typeArgs {
+		args[i] = symbolTypeName(t)
+		shapes[i] = "go.shape." + shapeTypeName(t)
+	}
+	symbol := symname.Mangle(name)
+	instance := symbol + "[" + strings.Join(args, ",") + "]"
+
+	if codePtr, err := findExeFunc(instance); err == nil {
+		CreateFuncForCodePtr(outFuncPtr, codePtr)
+		return nil
+	}
+
+	shape := symbol + "[" + strings.Join(shapes, ",") + "]"
+	shapePtr, err := findExeFunc(shape)
+	if err != nil {
+		return fmt.Errorf("Invalid generic function: neither %s nor %s found", instance, shape)
+	}
+	dictName, err := dictSymbolName(symbol, args)
+	if err != nil {
+		return err
+	}
+	dict, err := findExeSymbol(dictName)
+	if err != nil {
+		return err
+	}
+
+	// The shape function takes the dictionary as an extra first argument.
+	in := []reflect.Type{reflect.TypeOf(uintptr(0))}
+	for i := 0; i < fnType.NumIn(); i++ {
+		in = append(in, fnType.In(i))
+	}
+	out := make([]reflect.Type, fnType.NumOut())
+	for i := range out {
+		out[i] = fnType.Out(i)
+	}
+	shapeFunc := reflect.New(reflect.FuncOf(in, out, fnType.IsVariadic()))
+	CreateFuncForCodePtr(shapeFunc.Interface(), shapePtr)
+
+	dictVal := reflect.ValueOf(dict)
+	call := shapeFunc.Elem().Call
+	if fnType.IsVariadic() {
+		call = shapeFunc.Elem().CallSlice
+	}
+	wrapper := reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		return call(append([]reflect.Value{dictVal}, args...))
+	})
+	reflect.ValueOf(outFuncPtr).Elem().Set(wrapper)
+	return nil
+}
+
+// dictSymbolName returns the name of the dictionary of an instantiation, e.g.
+// slices..dict.Index[[]int,int] for slices.Index.
+func dictSymbolName(symbol string, args []string) (string, error) {
+	slash := strings.LastIndex(symbol, "/")
+	dot := strings.Index(symbol[slash+1:], ".")
+	if dot < 0 {
+		return "", fmt.Errorf("Invalid function name: %s", symbol)
+	}
+	dot += slash + 1
+	return symbol[:dot] + "..dict." + symbol[dot+1:] + "[" + strings.Join(args, ",") + "]", nil
+}
+
+// symbolTypeName spells t the way the compiler does in instantiated symbol
+// names: like Go syntax, but with full package paths.
+func symbolTypeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name() // predeclared
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+	return structuralTypeName(t)
+}
+
+// shapeTypeName spells the GC shape of t: its underlying type, except that
+// all pointers share the shape *uint8.
+func shapeTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr || t.Kind() == reflect.UnsafePointer {
+		return "*uint8"
+	}
+	return structuralTypeName(t)
+}
+
+// structuralTypeName spells t by its structure, ignoring its name.
+func structuralTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + symbolTypeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + symbolTypeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + symbolTypeName(t.Elem())
+	case reflect.Map:
+		return "map[" + symbolTypeName(t.Key()) + "]" + symbolTypeName(t.Elem())
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.SendDir:
+			return "chan<- " + symbolTypeName(t.Elem())
+		case reflect.RecvDir:
+			return "<-chan " + symbolTypeName(t.Elem())
+		}
+		return "chan " + symbolTypeName(t.Elem())
+	case reflect.Func:
+		return "func" + signatureTypeName(t)
+	case reflect.Struct:
+		if t.NumField() == 0 {
+			return "struct {}"
+		}
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			f := t.Field(i)
+			s := symbolTypeName(f.Type)
+			if !f.Anonymous {
+				s = qualifiedName(f.Name, f.PkgPath) + " " + s
+			}
+			if f.Tag != "" {
+				s += " " + strconv.Quote(string(f.Tag))
+			}
+			fields[i] = s
+		}
+		return "struct { " + strings.Join(fields, "; ") + " }"
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface {}"
+		}
+		methods := make([]string, t.NumMethod())
+		for i := range methods {
+			m := t.Method(i)
+			methods[i] = qualifiedName(m.Name, m.PkgPath) + signatureTypeName(m.Type)
+		}
+		return "interface { " + strings.Join(methods, "; ") + " }"
+	case reflect.UnsafePointer:
+		return "unsafe.Pointer"
+	}
+	return t.Kind().String()
+}
+
+func signatureTypeName(t reflect.Type) string {
+	in := make([]string, t.NumIn())
+	for i := range in {
+		if t.IsVariadic() && i == len(in)-1 {
+			in[i] = "..." + symbolTypeName(t.In(i).Elem())
+		} else {
+			in[i] = symbolTypeName(t.In(i))
+		}
+	}
+	s := "(" + strings.Join(in, ", ") + ")"
+	switch t.NumOut() {
+	case 0:
+		return s
+	case 1:
+		return s + " " + symbolTypeName(t.Out(0))
+	}
+	out := make([]string, t.NumOut())
+	for i := range out {
+		out[i] = symbolTypeName(t.Out(i))
+	}
+	return s + " (" + strings.Join(out, ", ") + ")"
+}
+
+// qualifiedName prefixes unexported field and method names with their
+// package path, as the compiler does.
+func qualifiedName(name, pkgPath string) string {
+	if pkgPath == "" {
+		return name
+	}
+	return pkgPath + "." + name
+}
@@ -0,0 +1,55 @@
+//go:build go1.21
+// +build go1.21
+
+package forceexport
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type genericPoint struct {
+	X, y int
+}
+
+// GetGenericFunc needs the symbol table, which go test strips from test
+// binaries, so the actual lookups are exercised by a separately built program.
+func TestGetGenericFunc(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "generic")
+	// Build with the toolchain running the test.
+	goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+	cmd := exec.Command(goCmd, "build", "-o", exe, "./testdata/generic")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cannot build test program: %v\n%s", err, out)
+	}
+	out, err := exec.Command(exe).CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "ok" {
+		t.Errorf("%s: %v\n%s", exe, err, out)
+	}
+}
+
+func TestShapeTypeName(t *testing.T) {
+	for _, c := range []struct {
+		typ         reflect.Type
+		name, shape string
+	}{
+		{reflect.TypeOf(0), "int", "int"},
+		{reflect.TypeOf(&genericPoint{}), "*github.com/szmcdull/go-forceexport.genericPoint", "*uint8"},
+		{reflect.TypeOf(genericPoint{}), "github.com/szmcdull/go-forceexport.genericPoint", "struct { X int; github.com/szmcdull/go-forceexport.y int }"},
+		{reflect.TypeOf(map[string][]byte{}), "map[string][]uint8", "map[string][]uint8"},
+		{reflect.TypeOf(func(int, ...string) error { return nil }), "func(int, ...string) error", "func(int, ...string) error"},
+		{reflect.TypeOf((*interface{})(nil)).Elem(), "interface {}", "interface {}"},
+		{reflect.TypeOf(make(chan<- int)), "chan<- int", "chan<- int"},
+	} {
+		if got := symbolTypeName(c.typ); got != c.name {
+			t.Errorf("symbolTypeName(%s) = %q, want %q", c.typ, got, c.name)
+		}
+		if got := shapeTypeName(c.typ); got != c.shape {
+			t.Errorf("shapeTypeName(%s) = %q, want %q", c.typ, got, c.shape)
+		}
+	}
+}
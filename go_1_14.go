@@ -40,6 +40,24 @@ type (
 		next *oldModuleWrapper
 	}
 
+	// _func is the header of a function's entry in the pclntab.
+	_func struct {
+		entry   uintptr // start pc
+		nameoff int32   // function name
+
+		args        int32  // in/out args size
+		deferreturn uint32 // offset of start of a deferreturn call instruction from entry, if any.
+
+		pcsp      int32
+		pcfile    int32
+		pcln      int32
+		npcdata   int32
+		funcID    uint8   // set for certain special runtime functions
+		flag      uint8   // padding; flags do not exist yet and this is always zero
+		_         [1]int8 // unused
+		nfuncdata uint8   // must be last
+	}
+
 	Bitvector struct {
 		n        int32 // # of bits
 		bytedata *uint8
@@ -54,6 +72,14 @@ func (me *oldModuleWrapper) GetFunc(ftab functab) *runtime.Func {
 	return (*runtime.Func)(unsafe.Pointer(&me.pclntable[ftab.funcoff]))
 }
 
+func (me *oldModuleWrapper) GetEntry(ftab functab) uintptr {
+	return ftab.entry
+}
+
+func (me *oldModuleWrapper) FindFunc(pc uintptr) int {
+	return findFuncIndex(me.ftab, me.findfunctab, me.minpc, me.maxpc, pc)
+}
+
 func (me *oldModuleWrapper) GetNext() moduleWrapper {
 	if me.next != nil {
 		return (*oldModuleWrapper)(unsafe.Pointer(me.next))
@@ -61,6 +87,47 @@ func (me *oldModuleWrapper) GetNext() moduleWrapper {
 	return nil
 }
 
+func (me *oldModuleWrapper) GetInfo() Module {
+	// Only the fields up to the section bounds match the runtime's layout;
+	// the rest of this struct predates Go 1.8.
+	return Module{
+		Text:  me.text,
+		EText: me.etext,
+		MinPC: me.minpc,
+		MaxPC: me.maxpc,
+		Sections: []ModuleSection{
+			{"text", me.text, me.etext},
+			{"noptrdata", me.noptrdata, me.enoptrdata},
+			{"data", me.data, me.edata},
+			{"bss", me.bss, me.ebss},
+			{"noptrbss", me.noptrbss, me.enoptrbss},
+		},
+	}
+}
+
+func (me *oldModuleWrapper) GetPCTab() []byte {
+	return me.pclntable
+}
+
+func (me *oldModuleWrapper) GetFuncFile(fn *_func, fileno int32) string {
+	if fileno < 0 || int(fileno) >= len(me.filetab) {
+		return "?"
+	}
+	return cString(me.pclntable, me.filetab[fileno])
+}
+
+func (me *oldModuleWrapper) GetFuncData(fn *_func, i uint8) unsafe.Pointer {
+	if i >= fn.nfuncdata {
+		return nil
+	}
+	// The funcdata pointers follow the pcdata offsets, pointer-aligned.
+	p := uintptr(unsafe.Pointer(&fn.nfuncdata)) + unsafe.Sizeof(fn.nfuncdata) + uintptr(fn.npcdata)*4
+	if unsafe.Sizeof(p) == 8 && p&4 != 0 {
+		p += 4
+	}
+	return *(*unsafe.Pointer)(unsafe.Pointer(p + uintptr(i)*unsafe.Sizeof(p)))
+}
+
 func getModuleWrapper() moduleWrapper {
 	old := &Firstmoduledata
 	// println(&Firstmoduledata)
@@ -70,3 +137,8 @@ func getModuleWrapper() moduleWrapper {
 
 //go:linkname Firstmoduledata runtime.firstmoduledata
 var Firstmoduledata oldModuleWrapper
+
+// getStartLine returns 0: the start line is only recorded since Go 1.20.
+func (me *_func) getStartLine() int {
+	return 0
+}
@@ -40,10 +40,10 @@ type (
 		ptab []byte
 
 		pluginpath string
-		pkghashes  []byte
+		pkghashes  []modulehash
 
 		modulename   string
-		modulehashes []byte
+		modulehashes []modulehash
 
 		hasmain uint8 // 1 if module contains the main function, 0 otherwise
 
@@ -56,6 +56,34 @@ type (
 		next *newModuleWrapper
 	}
 
+	// A modulehash is used to compare the ABI of a new module or a
+	// package in a new module with the loaded program.
+	modulehash struct {
+		modulename   string
+		linktimehash string
+		runtimehash  *string
+	}
+
+	// _func is the header of a function's entry in the pclntab. Go 1.16
+	// has no flag yet; the byte is padding and always zero.
+	_func struct {
+		entry   uintptr // start pc
+		nameoff int32   // function name
+
+		args        int32  // in/out args size
+		deferreturn uint32 // offset of start of a deferreturn call instruction from entry, if any.
+
+		pcsp      uint32
+		pcfile    uint32
+		pcln      uint32
+		npcdata   uint32
+		cuOffset  uint32  // runtime.cutab offset of this function's CU
+		funcID    uint8   // set for certain special runtime functions
+		flag      uint8   // funcFlag* bits
+		_         [1]byte // pad
+		nfuncdata uint8   // must be last, must end on a uint32-aligned boundary
+	}
+
 	Moduledata struct {
 		pcHeader *pcHeader
 	}
@@ -152,6 +180,60 @@ func (me *newModuleWrapper) GetNext() moduleWrapper {
 	return nil
 }
 
+func (me *newModuleWrapper) GetEntry(ftab functab) uintptr {
+	return ftab.entry
+}
+
+func (me *newModuleWrapper) FindFunc(pc uintptr) int {
+	return findFuncIndex(me.ftab, me.findfunctab, me.minpc, me.maxpc, pc)
+}
+
+func (me *newModuleWrapper) GetInfo() Module {
+	return Module{
+		Name:         me.modulename,
+		PluginPath:   me.pluginpath,
+		HasMain:      me.hasmain != 0,
+		Text:         me.text,
+		EText:        me.etext,
+		MinPC:        me.minpc,
+		MaxPC:        me.maxpc,
+		ModuleHashes: moduleHashes(me.modulehashes),
+		PkgHashes:    moduleHashes(me.pkghashes),
+		Sections: []ModuleSection{
+			{"text", me.text, me.etext},
+			{"noptrdata", me.noptrdata, me.enoptrdata},
+			{"data", me.data, me.edata},
+			{"bss", me.bss, me.ebss},
+			{"noptrbss", me.noptrbss, me.enoptrbss},
+			{"types", me.types, me.etypes},
+		},
+	}
+}
+
+func (me *newModuleWrapper) GetPCTab() []byte {
+	return me.pctab
+}
+
+func (me *newModuleWrapper) GetFuncFile(fn *_func, fileno int32) string {
+	i := int(fn.cuOffset) + int(fileno)
+	if fileno < 0 || i >= len(me.cutab) || me.cutab[i] == ^uint32(0) {
+		return "?"
+	}
+	return cString(me.filetab, me.cutab[i])
+}
+
+func (me *newModuleWrapper) GetFuncData(fn *_func, i uint8) unsafe.Pointer {
+	if i >= fn.nfuncdata {
+		return nil
+	}
+	// The funcdata pointers follow the pcdata offsets, pointer-aligned.
+	p := uintptr(unsafe.Pointer(&fn.nfuncdata)) + unsafe.Sizeof(fn.nfuncdata) + uintptr(fn.npcdata)*4
+	if unsafe.Sizeof(p) == 8 && p&4 != 0 {
+		p += 4
+	}
+	return *(*unsafe.Pointer)(unsafe.Pointer(p + uintptr(i)*unsafe.Sizeof(p)))
+}
+
 func getModuleWrapper() moduleWrapper {
 	new := (*newModuleWrapper)(unsafe.Pointer(&Firstmoduledata))
 	return new
@@ -159,3 +241,8 @@ func getModuleWrapper() moduleWrapper {
 
 //go:linkname Firstmoduledata runtime.firstmoduledata
 var Firstmoduledata Moduledata
+
+// getStartLine returns 0: the start line is only recorded since Go 1.20.
+func (me *_func) getStartLine() int {
+	return 0
+}
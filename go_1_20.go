@@ -0,0 +1,28 @@
+//go:build go1.20
+// +build go1.20
+
+package forceexport
+
+// _func is the header of a function's entry in the pclntab.
+type _func struct {
+	entryOff uint32 // start pc, as offset from moduledata.text/pcHeader.textStart
+	nameOff  int32  // function name, as index into moduledata.funcnametab.
+
+	args        int32  // in/out args size
+	deferreturn uint32 // offset of start of a deferreturn call instruction from entry, if any.
+
+	pcsp      uint32
+	pcfile    uint32
+	pcln      uint32
+	npcdata   uint32
+	cuOffset  uint32  // runtime.cutab offset of this function's CU
+	startLine int32   // line number of start of function (func keyword/TEXT directive)
+	funcID    uint8   // set for certain special runtime functions
+	flag      uint8   // funcFlag* bits
+	_         [1]byte // pad
+	nfuncdata uint8   // must be last, must end on a uint32-aligned boundary
+}
+
+func (me *_func) getStartLine() int {
+	return int(me.startLine)
+}
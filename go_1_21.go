@@ -38,60 +38,6 @@ type (
 	}
 )
 
-// moduledata records information about the layout of the executable
-// image. It is written by the linker. Any changes here must be
-// matched changes to the code in cmd/link/internal/ld/symtab.go:symtab.
-// moduledata is stored in statically allocated non-pointer memory;
-// none of the pointers here are visible to the garbage collector.
-type moduledata struct {
-	pcHeader     *pcHeader
-	funcnametab  []byte
-	cutab        []uint32
-	filetab      []byte
-	pctab        []byte
-	pclntable    []byte
-	ftab         []functab
-	findfunctab  uintptr
-	minpc, maxpc uintptr
-
-	text, etext           uintptr
-	noptrdata, enoptrdata uintptr
-	data, edata           uintptr
-	bss, ebss             uintptr
-	noptrbss, enoptrbss   uintptr
-	covctrs, ecovctrs     uintptr
-	end, gcdata, gcbss    uintptr
-	types, etypes         uintptr
-	rodata                uintptr
-	gofunc                uintptr // go.func.*
-
-	textsectmap []textsect
-	typelinks   []int32 // offsets from types
-	itablinks   []*itab
-
-	ptab []ptabEntry
-
-	pluginpath string
-	pkghashes  []modulehash
-
-	// This slice records the initializing tasks that need to be
-	// done to start up the program. It is built by the linker.
-	inittasks []uintptr //[]*initTask
-
-	modulename   string
-	modulehashes []modulehash
-
-	hasmain uint8 // 1 if module contains the main function, 0 otherwise
-
-	gcdatamask, gcbssmask bitvector
-
-	typemap map[typeOff]*_type // offset to *_rtype in previous module
-
-	bad bool // module failed to load and should be ignored
-
-	next *moduledata
-}
-
 type functab struct {
 	entryoff uint32 // relative to runtime.text
 	funcoff  uint32
@@ -201,3 +147,54 @@ func (me *newModuleWrapper) GetFunc(ftab functab) *runtime.Func {
 	return (*runtime.Func)(unsafe.Pointer(uintptr(unsafe.Pointer(me.pcHeader)) + uintptr(me.pcHeader.pclnOffset) + uintptr(ftab1_18.funcoff)))
 	//return (*runtime.Func)(unsafe.Pointer(&(*pcIntable)[ftab.funcoff]))
 }
+
+func (me *newModuleWrapper) GetEntry(ftab functab) uintptr {
+	return me.textAddr(ftab.entryoff)
+}
+
+func (me *newModuleWrapper) GetInfo() Module {
+	return Module{
+		Name:         me.modulename,
+		PluginPath:   me.pluginpath,
+		HasMain:      me.hasmain != 0,
+		Text:         me.text,
+		EText:        me.etext,
+		MinPC:        me.minpc,
+		MaxPC:        me.maxpc,
+		ModuleHashes: moduleHashes(me.modulehashes),
+		PkgHashes:    moduleHashes(me.pkghashes),
+		Sections: []ModuleSection{
+			{"text", me.text, me.etext},
+			{"noptrdata", me.noptrdata, me.enoptrdata},
+			{"data", me.data, me.edata},
+			{"bss", me.bss, me.ebss},
+			{"noptrbss", me.noptrbss, me.enoptrbss},
+			{"types", me.types, me.etypes},
+		},
+	}
+}
+
+func (me *newModuleWrapper) GetPCTab() []byte {
+	return me.pctab
+}
+
+func (me *newModuleWrapper) GetFuncFile(fn *_func, fileno int32) string {
+	i := int(fn.cuOffset) + int(fileno)
+	if fileno < 0 || i >= len(me.cutab) || me.cutab[i] == ^uint32(0) {
+		return "?"
+	}
+	return cString(me.filetab, me.cutab[i])
+}
+
+func (me *newModuleWrapper) GetFuncData(fn *_func, i uint8) unsafe.Pointer {
+	if i >= fn.nfuncdata {
+		return nil
+	}
+	// The funcdata are offsets from gofunc following the pcdata offsets.
+	p := uintptr(unsafe.Pointer(&fn.nfuncdata)) + unsafe.Sizeof(fn.nfuncdata) + uintptr(fn.npcdata)*4 + uintptr(i)*4
+	off := *(*uint32)(unsafe.Pointer(p))
+	if off == ^uint32(0) {
+		return nil
+	}
+	return unsafe.Pointer(me.gofunc + uintptr(off))
+}
@@ -4,10 +4,17 @@
 package forceexport
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
+
+	"github.com/szmcdull/go-forceexport/internal/pclntab"
 )
 
 // Go 1.23+ specific implementation: Due to linkname restrictions, use limited function discovery
@@ -25,25 +32,57 @@ type itab struct {
 	Fun   [1]uintptr // variable sized. fun[0]==0 means Type does not implement Inter.
 }
 
-type go123ModuleWrapper struct {
-	funcs []functab
-	next  *go123ModuleWrapper
-}
-
-func (m *go123ModuleWrapper) GetNext() moduleWrapper {
-	if m.next != nil {
-		return m.next
-	}
-	return nil
-}
-
-func (m *go123ModuleWrapper) GetFtab() []functab {
-	return m.funcs
-}
-
-func (m *go123ModuleWrapper) GetFunc(ftab functab) *runtime.Func {
-	pc := uintptr(ftab.entryoff)
-	return runtime.FuncForPC(pc)
+// moduledata records information about the layout of the executable
+// image. It is written by the linker. Any changes here must be
+// matched changes to the code in cmd/link/internal/ld/symtab.go:symtab.
+// moduledata is stored in statically allocated non-pointer memory;
+// none of the pointers here are visible to the garbage collector.
+type moduledata struct {
+	pcHeader     *pcHeader
+	funcnametab  []byte
+	cutab        []uint32
+	filetab      []byte
+	pctab        []byte
+	pclntable    []byte
+	ftab         []functab
+	findfunctab  uintptr
+	minpc, maxpc uintptr
+
+	text, etext           uintptr
+	noptrdata, enoptrdata uintptr
+	data, edata           uintptr
+	bss, ebss             uintptr
+	noptrbss, enoptrbss   uintptr
+	covctrs, ecovctrs     uintptr
+	end, gcdata, gcbss    uintptr
+	types, etypes         uintptr
+	rodata                uintptr
+	gofunc                uintptr // go.func.*
+
+	textsectmap []textsect
+	typelinks   []int32 // offsets from types
+	itablinks   []*itab
+
+	ptab []ptabEntry
+
+	pluginpath string
+	pkghashes  []modulehash
+
+	// This slice records the initializing tasks that need to be
+	// done to start up the program. It is built by the linker.
+	inittasks []uintptr //[]*initTask
+
+	modulename   string
+	modulehashes []modulehash
+
+	hasmain uint8 // 1 if module contains the main function, 0 otherwise
+	bad     bool  // module failed to load and should be ignored
+
+	gcdatamask, gcbssmask bitvector
+
+	typemap map[typeOff]*_type // offset to *_rtype in previous module
+
+	next *moduledata
 }
 
 func getModuleWrapper() moduleWrapper {
@@ -55,70 +94,207 @@ func getModuleWrapper() moduleWrapper {
 	return nil
 }
 
-var Firstmoduledata uintptr
-var FirstmoduledataAddrFromLinkname uintptr
-var firstModuleDataOnce sync.Once
-var codeAddr uintptr
+// moduleDataAddr is the address of runtime.firstmoduledata once it is known.
+// It is only accessed atomically; see SetModuleData.
+var moduleDataAddr uintptr
+
+// runtimePC is the entry of a known runtime function, which lies in the text
+// of the first module in every build mode.
+var runtimePC = reflect.ValueOf(runtime.GC).Pointer()
+
+// moduledata is usually in the data segment near the code segment, so the
+// search starts at the page of runtimePC.
+var codeAddr = runtimePC &^ 0xFFF
+
+// discovery serializes the search for runtime.firstmoduledata. Once a search
+// with the global Config fails without being aborted, failed keeps later
+// lookups from repeating it until SetConfig is called.
+var discovery struct {
+	sync.Mutex
+	failed error
+}
 
 // scan memory for runtime.firstmoduledata
 func findFirstModuleData() uintptr {
-	if Firstmoduledata != 0 {
-		return Firstmoduledata
+	if addr := atomic.LoadUintptr(&moduleDataAddr); addr != 0 {
+		return addr
 	}
-	if FirstmoduledataAddrFromLinkname != 0 {
-		return FirstmoduledataAddrFromLinkname
+	addr, _ := discoverFirstModuleData(currentConfig(), true)
+	return addr
+}
+
+// discoverModuleData finds the module data with config, or with the global
+// Config if config is nil.
+func discoverModuleData(config *Config) error {
+	if config == nil {
+		_, err := discoverFirstModuleData(currentConfig(), true)
+		return err
 	}
-	if FirstmoduledataFromLinkName.pcHeader != nil {
-		FirstmoduledataAddrFromLinkname = uintptr(unsafe.Pointer(&FirstmoduledataFromLinkName))
-		return FirstmoduledataAddrFromLinkname
+	_, err := discoverFirstModuleData(config.withDefaults(), false)
+	return err
+}
+
+func resetModuleDataDiscovery() {
+	discovery.Lock()
+	discovery.failed = nil
+	discovery.Unlock()
+}
+
+func setModuleData(addr uintptr) error {
+	config := currentConfig()
+	if !isValidModuleData(addr, &config) {
+		return fmt.Errorf("no moduledata at %#x", addr)
 	}
+	atomic.StoreUintptr(&moduleDataAddr, addr)
+	return nil
+}
 
-	firstModuleDataOnce.Do(func() {
+// discoverFirstModuleData tries the strategies of config in turn. If global
+// is set, failures are remembered in discovery.failed.
+func discoverFirstModuleData(config Config, global bool) (uintptr, error) {
+	if addr := atomic.LoadUintptr(&moduleDataAddr); addr != 0 {
+		return addr, nil
+	}
+	// The linker fills in the linknamed variable before the program starts.
+	if config.Strategies&StrategyLinkname != 0 && firstmoduledataFromLinkname.pcHeader != nil {
+		return uintptr(unsafe.Pointer(&firstmoduledataFromLinkname)), nil
+	}
 
-		// Strategy 1: Locate nearby data structures using known runtime function addresses
-		pc := reflect.ValueOf(runtime.GC).Pointer()
+	discovery.Lock()
+	defer discovery.Unlock()
+	if addr := atomic.LoadUintptr(&moduleDataAddr); addr != 0 {
+		return addr, nil
+	}
+	if global && discovery.failed != nil {
+		return 0, discovery.failed
+	}
 
-		// moduledata is usually in the data segment near the code segment
-		// Search range: start from the current PC address, search forward and backward
-		codeAddr = pc & ^uintptr(0xFFF) // Page aligned
+	if config.Strategies&StrategyAsm != 0 {
+		if addr := asmModuleData(); addr != 0 && isValidModuleData(addr, &config) {
+			config.logf("forceexport: moduledata at %#x from assembly", addr)
+			atomic.StoreUintptr(&moduleDataAddr, addr)
+			return addr, nil
+		}
+	}
 
-		// Search for moduledata features within a reasonable range
-		// Use a more conservative search range and step size
-		for offset := uintptr(0); offset < 0x2000000; offset += uintptr(unsafe.Sizeof(uintptr(0))) { // Search 32MB range, step by pointer size
-			// Search forward
-			if addr := codeAddr + offset; isValidModuleData(addr) {
-				Firstmoduledata = addr
-				// fmt.Printf("Found moduledata at: %x, base addr: %x, offset=%x, FirstmoduledataFromLinkName=%x\n", addr, baseAddr, offset, FirstmoduledataAddrFromLinkname)
-				return
-			}
+	// A previous run of the same binary may have recorded where it is
+	if config.Strategies&StrategyCache != 0 {
+		if addr := loadCachedModuleData(codeAddr, &config); addr != 0 {
+			config.logf("forceexport: moduledata at %#x from the cache", addr)
+			atomic.StoreUintptr(&moduleDataAddr, addr)
+			return addr, nil
+		}
+	}
+	if config.Strategies&StrategyScan == 0 {
+		return 0, fmt.Errorf("moduledata not found: scanning is disabled")
+	}
 
-			// Search backward
-			if codeAddr > offset && codeAddr-offset > 0x400000 { // Ensure not to search too low addresses
-				if addr := codeAddr - offset; isValidModuleData(addr) {
-					Firstmoduledata = addr
-					// fmt.Printf("Found moduledata at: %x, base addr: %x, offset=%x, FirstmoduledataFromLinkName=%x\n", addr, baseAddr, offset, FirstmoduledataAddrFromLinkname)
-					return
-				}
+	addr, err := scanModuleData(&config)
+	if err != nil {
+		return 0, err
+	}
+	if addr == 0 {
+		err := fmt.Errorf("moduledata not found within %#x bytes of the code at %#x", config.ScanRange, codeAddr)
+		config.logf("forceexport: %v", err)
+		if global {
+			discovery.failed = err
+		}
+		return 0, err
+	}
+	atomic.StoreUintptr(&moduleDataAddr, addr)
+	if config.Strategies&StrategyCache != 0 {
+		storeCachedModuleData(codeAddr, addr, &config)
+	}
+	return addr, nil
+}
+
+// scanModuleData searches around codeAddr for the moduledata. It returns 0
+// if there is none in range and an error if the scan was aborted.
+func scanModuleData(config *Config) (uintptr, error) {
+	ctx := config.Context
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+	const (
+		chunk    = 0x1000   // bytes read at once, aligned like codeAddr
+		logEvery = 0x400000 // bytes scanned between progress messages
+	)
+	start := time.Now()
+	config.logf("forceexport: scanning %#x bytes around %#x for moduledata", config.ScanRange, codeAddr)
+
+	// Reading the memory a chunk at a time and only looking closer at words
+	// that could be the pcHeader pointer keeps the number of reads (system
+	// calls on most platforms) low.
+	var words [chunk / unsafe.Sizeof(uintptr(0))]uintptr
+	scanChunk := func(base uintptr) uintptr {
+		if SafeRead(base, (*[chunk]byte)(unsafe.Pointer(&words))[:]) != nil {
+			return 0
+		}
+		for i, w := range words {
+			if w%unsafe.Sizeof(w) != 0 || !isInCodeSection(w, config) {
+				continue
+			}
+			if addr := base + uintptr(i)*unsafe.Sizeof(w); isValidModuleData(addr, config) {
+				return addr
 			}
 		}
+		return 0
+	}
 
-	})
+	// Search for moduledata features within a reasonable range
+	for offset := uintptr(0); offset < config.ScanRange; offset += chunk {
+		if ctx.Err() != nil {
+			config.logf("forceexport: moduledata scan aborted after %#x bytes: %v", offset, ctx.Err())
+			return 0, fmt.Errorf("moduledata scan aborted: %v", ctx.Err())
+		}
+		if offset%logEvery == 0 && offset != 0 {
+			config.logf("forceexport: scanned %#x of %#x bytes in %s", offset, config.ScanRange, time.Since(start))
+		}
+
+		// Search forward
+		if addr := scanChunk(codeAddr + offset); addr != 0 {
+			config.logf("forceexport: moduledata at %#x after %s", addr, time.Since(start))
+			return addr, nil
+		}
 
-	return Firstmoduledata
+		// Search backward
+		if codeAddr > offset+chunk && codeAddr-offset-chunk >= config.MinScanAddr { // Ensure not to search too low addresses
+			if addr := scanChunk(codeAddr - offset - chunk); addr != 0 {
+				config.logf("forceexport: moduledata at %#x after %s", addr, time.Since(start))
+				return addr, nil
+			}
+		}
+	}
+	return 0, nil
 }
 
-func isInCodeSection(addr uintptr) bool {
+func isInCodeSection(addr uintptr, config *Config) bool {
 	offset := int(addr) - int(codeAddr)
-	if offset > 0x40000000 || offset < -200000 {
+	if offset > int(config.PCHeaderAfter) || offset < -int(config.PCHeaderBefore) {
 		return false
 	}
 	return true
 }
 
+// isValidPCHeader checks the start of a candidate pcHeader with the rules
+// package proc also applies to other processes. The magic is decoded in native
+// byte order, so a big-endian header matches only on a big-endian
+// architecture, and the pointer size and instruction size must be those the
+// running binary was built for.
+func isValidPCHeader(b []byte, config *Config) bool {
+	header, ok := pclntab.ParseHeader(b, binary.NativeEndian)
+	if !ok || header.Magic != 0xFFFFFFF1 && header.Magic != 0xFFFFFFF0 {
+		return false
+	}
+	return header.Check(int(ptrSize), int(pcQuantum), uint64(config.MaxFuncs)) // Reasonable function count range
+}
+
 // Check whether the given address is likely a moduledata structure
-func isValidModuleData(addr uintptr) bool {
+func isValidModuleData(addr uintptr, config *Config) bool {
 	// Basic address check
-	if addr == 0 || addr < 0x1000 || addr == 0xffffffffffffffff {
+	if addr == 0 || addr < 0x1000 || addr == ^uintptr(0) {
 		return false
 	}
 
@@ -140,88 +316,45 @@ func isValidModuleData(addr uintptr) bool {
 	// }
 
 	// Try to safely read the pcHeader pointer
-	IsAddrReadable(pcHeaderPtrAddr, int(unsafe.Sizeof(pcHeader{})))
 	pcHeaderAddr, ok := safeReadUintptr(pcHeaderPtrAddr)
 	if !ok {
 		return false
 	}
 
-	if !isInCodeSection(pcHeaderAddr) {
+	if !isInCodeSection(pcHeaderAddr, config) {
 		return false
 	}
 
-	if pcHeaderAddr == 0 || pcHeaderAddr < 0x1000 || pcHeaderAddr == 0xffffffffffffffff {
+	if pcHeaderAddr == 0 || pcHeaderAddr < 0x1000 || pcHeaderAddr == ^uintptr(0) {
 		return false
 	}
 
-	// Check whether pcHeader is readable
-	const pcHeaderSize = 32 // Estimated size of pcHeader structure
-	if !IsAddrReadable(pcHeaderAddr, pcHeaderSize) {
+	// Try to safely read the pcHeader
+	var header [pclntab.HeaderSize]byte
+	if SafeRead(pcHeaderAddr, header[:]) != nil {
 		return false
 	}
 
-	// Try to safely read the magic value
-	magic, ok := safeReadUintptr(pcHeaderAddr)
-	if !ok {
+	if !isValidPCHeader(header[:], config) {
 		return false
 	}
 
-	magicAndPads := magic & 0xffffffffffff
-	if magicAndPads != 0xFFFFFFF1 && magicAndPads != 0xFFFFFFF0 {
+	// The first module is the one holding the runtime. Only executables have
+	// hasmain set (c-shared and c-archive builds do not), so check that its
+	// text contains the runtime instead; this also rules out plugins.
+	minpc, ok := safeReadUintptr(addr + unsafe.Offsetof(moduledata{}.minpc))
+	if !ok || runtimePC < minpc {
 		return false
 	}
-
-	// Try to safely read the nfunc field
-	nfunc, ok := safeReadUint32(pcHeaderAddr + 8) // nfunc usually follows magic
-	if !ok {
+	maxpc, ok := safeReadUintptr(addr + unsafe.Offsetof(moduledata{}.maxpc))
+	if !ok || runtimePC >= maxpc {
 		return false
 	}
-
-	if nfunc == 0 || nfunc > 100000 { // Reasonable function count range
-		return false
-	}
-
-	moduleData := (*moduledata)(unsafe.Pointer(addr))
-	if moduleData.hasmain != 1 {
+	hasmain, ok := safeReadUint8(addr + unsafe.Offsetof(moduledata{}.hasmain))
+	if !ok || hasmain > 1 {
 		return false
 	}
 
 	// If all checks pass, consider this a valid moduledata
 	return true
 }
-
-// Safely read uintptr value
-func safeReadUintptr(addr uintptr) (value uintptr, ok bool) {
-	defer func() {
-		if recover() != nil {
-			ok = false
-			value = 0
-		}
-	}()
-
-	if !IsAddrReadable(addr, int(unsafe.Sizeof(uintptr(0)))) {
-		return 0, false
-	}
-
-	value = *(*uintptr)(unsafe.Pointer(addr))
-	ok = true
-	return
-}
-
-// Safely read uint32 value
-func safeReadUint32(addr uintptr) (value uint32, ok bool) {
-	defer func() {
-		if recover() != nil {
-			ok = false
-			value = 0
-		}
-	}()
-
-	if !IsAddrReadable(addr, 4) {
-		return 0, false
-	}
-
-	value = *(*uint32)(unsafe.Pointer(addr))
-	ok = true
-	return
-}
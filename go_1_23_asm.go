@@ -0,0 +1,20 @@
+//go:build go1.23 && (amd64 || arm64) && !forceexport_noasm
+// +build go1.23
+// +build amd64 arm64
+// +build !forceexport_noasm
+
+package forceexport
+
+// lastModuleData returns runtime.lastmoduledatap. It is implemented in
+// assembly because go:linkname of runtime variables needs -checklinkname=0,
+// while the linker lets assembly reference lastmoduledatap, which the runtime
+// exports for such uses. runtime.firstmoduledata itself is rejected either way.
+func lastModuleData() uintptr
+
+// asmModuleData returns the address of runtime.firstmoduledata as seen from
+// assembly. Until a plugin is loaded the last module is the first one;
+// afterwards lastmoduledatap points into the plugin, whose moduledata fails
+// isValidModuleData because its text does not contain the runtime.
+func asmModuleData() uintptr {
+	return lastModuleData()
+}
@@ -0,0 +1,10 @@
+//go:build go1.23 && !forceexport_noasm
+// +build go1.23,!forceexport_noasm
+
+#include "textflag.h"
+
+// func lastModuleData() uintptr
+TEXT ·lastModuleData(SB), NOSPLIT, $0-8
+	MOVD	runtime·lastmoduledatap(SB), R0
+	MOVD	R0, ret+0(FP)
+	RET
@@ -0,0 +1,82 @@
+//go:build go1.23
+// +build go1.23
+
+package forceexport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The offset of the moduledata from the text base is stored in Config.CacheDir
+// in a file named after the executable's Go build ID, and later starts of the
+// same binary check that offset with isValidModuleData instead of scanning. A
+// rebuilt binary has a new build ID and scans again.
+
+// moduleDataCacheFile returns the cache file of the running executable, or ""
+// if caching is disabled or the build ID is unknown.
+func moduleDataCacheFile(config *Config) string {
+	if config.CacheDir == "" {
+		return ""
+	}
+	buildID, err := executableBuildID()
+	if err != nil || buildID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(buildID))
+	return filepath.Join(config.CacheDir, "forceexport-moduledata-"+hex.EncodeToString(sum[:12]))
+}
+
+// loadCachedModuleData returns the moduledata address recorded for this
+// executable relative to base, or 0 if there is none or it does not check out.
+func loadCachedModuleData(base uintptr, config *Config) uintptr {
+	file := moduleDataCacheFile(config)
+	if file == "" {
+		return 0
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	if addr := base + uintptr(offset); isValidModuleData(addr, config) {
+		return addr
+	}
+	return 0
+}
+
+// storeCachedModuleData records addr relative to base for this executable.
+// Failures only cost a scan on the next start and are ignored.
+func storeCachedModuleData(base, addr uintptr, config *Config) {
+	file := moduleDataCacheFile(config)
+	if file == "" {
+		return
+	}
+	if err := os.MkdirAll(config.CacheDir, 0755); err != nil {
+		return
+	}
+	// Write to a temporary file first so that concurrent starts never read a
+	// partial offset.
+	tmp, err := ioutil.TempFile(config.CacheDir, filepath.Base(file)+".tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.WriteString(strconv.FormatInt(int64(addr-base), 10) + "\n")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
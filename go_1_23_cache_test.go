@@ -0,0 +1,41 @@
+//go:build go1.23
+// +build go1.23
+
+package forceexport
+
+import (
+	"io/ioutil"
+	"strconv"
+	"testing"
+)
+
+func TestModuleDataCache(t *testing.T) {
+	addr := findFirstModuleData()
+	if addr == 0 {
+		t.Skip("moduledata not found")
+	}
+	SetConfig(Config{CacheDir: t.TempDir()})
+	defer SetConfig(Config{})
+	config := currentConfig()
+	file := moduleDataCacheFile(&config)
+	if file == "" {
+		t.Skip("executable has no build ID")
+	}
+
+	if loadCachedModuleData(codeAddr, &config) != 0 {
+		t.Error("Expected an empty cache to miss.")
+	}
+	storeCachedModuleData(codeAddr, addr, &config)
+	if got := loadCachedModuleData(codeAddr, &config); got != addr {
+		t.Errorf("Expected the cached moduledata at %#x, got %#x.", addr, got)
+	}
+
+	// An offset from another build fails validation.
+	stale := strconv.FormatInt(int64(addr-codeAddr)+64, 10)
+	if err := ioutil.WriteFile(file, []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := loadCachedModuleData(codeAddr, &config); got != 0 {
+		t.Errorf("Expected a stale offset to be rejected, got %#x.", got)
+	}
+}
@@ -0,0 +1,42 @@
+//go:build go1.23 && linux
+// +build go1.23,linux
+
+package forceexport
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Without process_vm_readv, every chunk the scan reads is checked against
+// /proc/self/maps; rereading it for each unmapped chunk made a failing scan
+// take minutes in processes with many mappings.
+func TestScanModuleDataFallback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("scans the whole range")
+	}
+	atomic.StoreUint32(&vmReadvUnavailable, 1)
+	defer atomic.StoreUint32(&vmReadvUnavailable, 0)
+
+	// No pcHeader counts one function or less, so the scan visits the
+	// whole default range and fails.
+	config := Config{MaxFuncs: 1}.withDefaults()
+	procMaps.Lock()
+	reads := procMaps.reads
+	procMaps.Unlock()
+	start := time.Now()
+	addr, err := scanModuleData(&config)
+	elapsed := time.Since(start)
+	if addr != 0 || err != nil {
+		t.Fatalf("Expected the scan to fail, got %#x, %v.", addr, err)
+	}
+
+	procMaps.Lock()
+	reads = procMaps.reads - reads
+	procMaps.Unlock()
+	t.Logf("failing fallback scan took %s and read the maps %d times", elapsed, reads)
+	if max := int(elapsed/mapsRefreshInterval) + 1; reads > max {
+		t.Errorf("Expected at most %d rereads of /proc/self/maps in %s, got %d.", max, elapsed, reads)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build go1.23 && ((!amd64 && !arm64) || forceexport_noasm)
+// +build go1.23
+// +build !amd64,!arm64 forceexport_noasm
+
+package forceexport
+
+// asmModuleData has no assembly implementation on this architecture, or it
+// was disabled with -tags=forceexport_noasm for toolchains whose linker
+// rejects it; discovery goes on with the next strategy.
+func asmModuleData() uintptr {
+	return 0
+}
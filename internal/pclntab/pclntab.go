@@ -0,0 +1,91 @@
+// Package pclntab recognizes and decodes Go function tables (the runtime's
+// pclntab) read from a binary file or from the memory of another process.
+package pclntab
+
+import (
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+)
+
+// Go12Magic is the magic number of the function tables of Go 1.2 to 1.15,
+// which are laid out without a pcHeader.
+const Go12Magic = 0xfffffffb
+
+// Magics are the magic numbers of the function tables of Go 1.2, 1.16, 1.18
+// and 1.20 and later.
+var Magics = []uint32{Go12Magic, 0xfffffffa, 0xfffffff0, 0xfffffff1}
+
+// MaxFuncs is the largest function count of a plausible header found by
+// scanning memory; forceexport.DefaultMaxFuncs is the same.
+const MaxFuncs = 100000
+
+// HeaderSize is the number of bytes ParseHeader needs.
+const HeaderSize = 16
+
+// Header holds the fixed fields at the start of a function table.
+type Header struct {
+	Magic   uint32
+	MinLC   uint8  // instruction size quantum
+	PtrSize uint8  // pointer size of the target
+	NFunc   uint64 // number of functions
+}
+
+// ParseHeader decodes the header at the start of b with the given byte order
+// and reports whether it is plausible: a known magic number, two zero pad
+// bytes, an instruction size quantum of 1, 2 or 4, a pointer size of 4 or 8
+// and at least one function. The in-process lookup applies the same rules to
+// the running binary's own pcHeader.
+func ParseHeader(b []byte, order binary.ByteOrder) (Header, bool) {
+	if len(b) < 8 {
+		return Header{}, false
+	}
+	h := Header{Magic: order.Uint32(b), MinLC: b[6], PtrSize: b[7]}
+	if !isMagic(h.Magic) || b[4] != 0 || b[5] != 0 {
+		return h, false
+	}
+	if h.MinLC != 1 && h.MinLC != 2 && h.MinLC != 4 {
+		return h, false
+	}
+	switch {
+	case h.PtrSize == 4 && len(b) >= 12:
+		h.NFunc = uint64(order.Uint32(b[8:]))
+	case h.PtrSize == 8 && len(b) >= 16:
+		h.NFunc = order.Uint64(b[8:])
+	default:
+		return h, false
+	}
+	return h, h.NFunc > 0
+}
+
+// Check reports whether the header was written for a target with the given
+// pointer size and instruction size quantum and has at most maxFuncs
+// functions.
+func (me Header) Check(ptrSize, minLC int, maxFuncs uint64) bool {
+	return int(me.PtrSize) == ptrSize && int(me.MinLC) == minLC && me.NFunc <= maxFuncs
+}
+
+func isMagic(magic uint32) bool {
+	for _, m := range Magics {
+		if magic == m {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTable decodes a function table, turning the panics debug/gosym may raise
+// on malformed input into errors. textStart is the address of the text
+// section, which tables before Go 1.18 do not record.
+func NewTable(data []byte, textStart uint64) (table *gosym.Table, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			table, err = nil, fmt.Errorf("corrupt function table: %v", r)
+		}
+	}()
+	table, err = gosym.NewTable(nil, gosym.NewLineTable(data, textStart))
+	if err == nil && len(table.Funcs) == 0 {
+		table, err = nil, fmt.Errorf("function table has no functions")
+	}
+	return table, err
+}
@@ -0,0 +1,30 @@
+package pclntab
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		b     []byte
+		order binary.ByteOrder
+		want  bool
+	}{
+		{"amd64", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 8, 100, 0, 0, 0, 0, 0, 0, 0}, binary.LittleEndian, true},
+		{"386", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 4, 100, 0, 0, 0}, binary.LittleEndian, true},
+		{"s390x", []byte{0xff, 0xff, 0xff, 0xf1, 0, 0, 2, 8, 0, 0, 0, 0, 0, 0, 0, 100}, binary.BigEndian, true},
+		{"mips go1.2", []byte{0xff, 0xff, 0xff, 0xfb, 0, 0, 4, 4, 0, 0, 0, 100}, binary.BigEndian, true},
+		{"wrong order", []byte{0xff, 0xff, 0xff, 0xf1, 0, 0, 2, 8, 0, 0, 0, 0, 0, 0, 0, 100}, binary.LittleEndian, false},
+		{"pad", []byte{0xf1, 0xff, 0xff, 0xff, 1, 0, 1, 8, 100, 0, 0, 0, 0, 0, 0, 0}, binary.LittleEndian, false},
+		{"minLC", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 3, 8, 100, 0, 0, 0, 0, 0, 0, 0}, binary.LittleEndian, false},
+		{"ptrSize", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 2, 100, 0, 0, 0}, binary.LittleEndian, false},
+		{"no funcs", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 8, 0, 0, 0, 0, 0, 0, 0, 0}, binary.LittleEndian, false},
+		{"short", []byte{0xf1, 0xff, 0xff, 0xff, 0, 0, 1, 8}, binary.LittleEndian, false},
+	} {
+		if h, ok := ParseHeader(c.b, c.order); ok != c.want || ok && h.NFunc != 100 {
+			t.Errorf("%s: ParseHeader = %+v, %v, want valid %v with 100 functions", c.name, h, ok, c.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package procmaps parses the memory maps Linux publishes in /proc/<pid>/maps.
+package procmaps
+
+import (
+	"bufio"
+	"bytes"
+	"sort"
+	"strconv"
+)
+
+// Region is one line of /proc/<pid>/maps.
+type Region struct {
+	Start, End uint64
+	Perms      string // e.g. "r-xp"
+	Offset     uint64 // offset in the mapped file
+	Path       string // mapped file or pseudo-path such as [heap]; may be empty
+}
+
+// Readable reports whether the region is mapped readable.
+func (me *Region) Readable() bool {
+	return len(me.Perms) > 0 && me.Perms[0] == 'r'
+}
+
+// Writable reports whether the region is mapped writable.
+func (me *Region) Writable() bool {
+	return len(me.Perms) > 1 && me.Perms[1] == 'w'
+}
+
+// Parse parses the contents of /proc/<pid>/maps, whose lines look like
+//
+//	00400000-0048d000 r-xp 00000000 fd:01 1234    /usr/bin/prog
+//
+// and returns the regions sorted by start address.
+func Parse(data []byte) ([]Region, error) {
+	var regions []Region
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) < 5 {
+			continue
+		}
+		dash := bytes.IndexByte(fields[0], '-')
+		if dash < 0 {
+			return nil, strconv.ErrSyntax
+		}
+		start, err := strconv.ParseUint(string(fields[0][:dash]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		end, err := strconv.ParseUint(string(fields[0][dash+1:]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		offset, err := strconv.ParseUint(string(fields[2]), 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		r := Region{Start: start, End: end, Perms: string(fields[1]), Offset: offset}
+		if len(fields) > 5 {
+			r.Path = string(bytes.Join(fields[5:], []byte(" ")))
+		}
+		regions = append(regions, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Start < regions[j].Start })
+	return regions, nil
+}
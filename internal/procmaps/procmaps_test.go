@@ -0,0 +1,32 @@
+package procmaps
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	regions, err := Parse([]byte(`7ffd1000-7ffd2000 rw-p 00000000 00:00 0                          [stack]
+00400000-0048d000 r-xp 00001000 fd:01 1234                       /usr/bin/my prog
+0048d000-0048e000 ---p 00000000 00:00 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Region{
+		{Start: 0x400000, End: 0x48d000, Perms: "r-xp", Offset: 0x1000, Path: "/usr/bin/my prog"},
+		{Start: 0x48d000, End: 0x48e000, Perms: "---p"},
+		{Start: 0x7ffd1000, End: 0x7ffd2000, Perms: "rw-p", Path: "[stack]"},
+	}
+	if len(regions) != len(want) {
+		t.Fatalf("Parse returned %d regions, want %d", len(regions), len(want))
+	}
+	for i, r := range regions {
+		if r != want[i] {
+			t.Errorf("region %d = %+v, want %+v", i, r, want[i])
+		}
+	}
+	if !regions[0].Readable() || regions[0].Writable() || regions[1].Readable() || !regions[2].Writable() {
+		t.Error("wrong permissions")
+	}
+	if _, err := Parse([]byte("zz-10 r--p 0 0 0\n")); err == nil {
+		t.Error("Parse accepted a malformed line")
+	}
+}
@@ -0,0 +1,16 @@
+// Package symname converts between the function names users write, in the
+// form accepted by forceexport.GetFunc, and the names the linker records.
+package symname
+
+import "strings"
+
+// Mangle converts a user-facing name to the name recorded in the function
+// table and symbol table: the linker escapes the dot in a leading "go."
+// package element, so that go.shape.int becomes go%2eshape.int, while import
+// paths such as go.opentelemetry.io/otel are kept.
+func Mangle(name string) string {
+	if strings.HasPrefix(name, `go.`) && !strings.Contains(name, `/`) {
+		name = strings.Replace(name, `go.`, `go%2e`, 1)
+	}
+	return name
+}
@@ -0,0 +1,16 @@
+package symname
+
+import "testing"
+
+func TestMangle(t *testing.T) {
+	for name, want := range map[string]string{
+		"time.now":                "time.now",
+		"go.opentelemetry.io/x.f": "go.opentelemetry.io/x.f",
+		"go.shape.int":            "go%2eshape.int",
+		"net/http.(*Client).do":   "net/http.(*Client).do",
+	} {
+		if got := Mangle(name); got != want {
+			t.Errorf("Mangle(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+//go:build go1.21
+// +build go1.21
+
+package forceexport
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy is a handle to a function that is looked up by name the first time it
+// is needed rather than at init time. F must be a function type matching the
+// real signature, e.g.
+//
+//	var nanotime = forceexport.Lazy[func() int64]{Name: "runtime.nanotime"}
+//
+//	t, err := nanotime.Get()
+//
+// The lookup runs exactly once, even when Get is called from many goroutines
+// at the same time; the resulting function or error is cached. A Lazy must not
+// be copied after first use.
+type Lazy[F any] struct {
+	// Name is the fully-qualified function name, as passed to GetFunc.
+	Name string
+
+	once     sync.Once
+	resolved uint32 // set with atomic once fn and err are final
+	fn       F
+	err      error
+}
+
+// Get returns the function, resolving it on the first call. If the function
+// cannot be found, the zero F and the (cached) error are returned.
+func (me *Lazy[F]) Get() (F, error) {
+	me.once.Do(me.resolve)
+	return me.fn, me.err
+}
+
+// Resolved reports whether the lookup has already run, successfully or not.
+func (me *Lazy[F]) Resolved() bool {
+	return atomic.LoadUint32(&me.resolved) != 0
+}
+
+// Err returns the error of the lookup, or nil if it succeeded or has not run
+// yet. Unlike Get it never triggers the lookup.
+func (me *Lazy[F]) Err() error {
+	if !me.Resolved() {
+		return nil
+	}
+	return me.err
+}
+
+func (me *Lazy[F]) resolve() {
+	defer atomic.StoreUint32(&me.resolved, 1)
+
+	if t := reflect.TypeOf(&me.fn).Elem(); t.Kind() != reflect.Func {
+		me.err = fmt.Errorf("Lazy[%s]: type argument must be a function type", t)
+		return
+	}
+	// GetFunc writes into a local first so that a concurrent reader can
+	// never observe a half-built function value.
+	var fn F
+	if err := GetFunc(&fn, me.Name); err != nil {
+		me.err = err
+		return
+	}
+	me.fn = fn
+}
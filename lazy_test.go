@@ -0,0 +1,53 @@
+//go:build go1.21
+// +build go1.21
+
+package forceexport
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	lazy := Lazy[func(int) int]{Name: "github.com/szmcdull/go-forceexport.addOne"}
+	if lazy.Resolved() || lazy.Err() != nil {
+		t.Fatal("Expected an unresolved handle.")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := lazy.Get()
+			if err != nil {
+				t.Error("Expected nil error.")
+				return
+			}
+			if f(3) != 4 {
+				t.Error("Expected the function to add one to 3.")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if !lazy.Resolved() || lazy.Err() != nil {
+		t.Error("Expected a successfully resolved handle.")
+	}
+}
+
+func TestLazyInvalid(t *testing.T) {
+	lazy := Lazy[func()]{Name: "invalidpackage.invalidfunction"}
+	f, err := lazy.Get()
+	if err == nil || f != nil {
+		t.Error("Expected an error and a nil function.")
+	}
+	if !lazy.Resolved() || lazy.Err() != err {
+		t.Error("Expected the error to be cached.")
+	}
+
+	notFunc := Lazy[int]{Name: "github.com/szmcdull/go-forceexport.addOne"}
+	if _, err := notFunc.Get(); err == nil {
+		t.Error("Expected an error for a non-function type.")
+	}
+}
@@ -1,8 +1,12 @@
 //go:build !windows && !linux && !darwin && !unix
+// +build !windows,!linux,!darwin,!unix
 
 package forceexport
 
 import (
+	"errors"
+	"fmt"
+	"runtime"
 	"unsafe"
 )
 
@@ -14,7 +18,7 @@ func IsAddrReadable(addr uintptr, size int) bool {
 	}
 
 	// 基本范围检查
-	if addr < 0x1000 || addr == 0xffffffffffffffff {
+	if addr < 0x1000 || addr == ^uintptr(0) {
 		return false
 	}
 
@@ -49,3 +53,19 @@ func fallbackAddressCheck(addr uintptr, size int) bool {
 
 	return false
 }
+
+func memoryRegions() ([]MemoryRegion, error) {
+	return nil, fmt.Errorf("MemoryRegions is not supported on %s", runtime.GOOS)
+}
+
+var errFault error = errors.New("bad address")
+
+// SafeRead copies len(buf) bytes at addr into buf, returning an error instead
+// of crashing if the memory is not readable. On this platform the range is
+// checked with IsAddrReadable and copied under debug.SetPanicOnFault.
+func SafeRead(addr uintptr, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	return readWithRecover(addr, buf)
+}
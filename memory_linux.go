@@ -0,0 +1,187 @@
+//go:build linux
+// +build linux
+
+package forceexport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/szmcdull/go-forceexport/internal/procmaps"
+)
+
+// memRegion is one line of /proc/self/maps.
+type memRegion struct {
+	start, end uintptr
+	perms      string // e.g. "r-xp"
+	path       string // mapped file or pseudo-path such as [heap]; may be empty
+}
+
+func (me *memRegion) readable() bool {
+	return len(me.perms) > 0 && me.perms[0] == 'r'
+}
+
+// mapsRefreshInterval limits how often lookup misses reread /proc/self/maps,
+// so that scans probing many unmapped addresses stay cheap. A mapping created
+// less than the interval after the last reread may be missed.
+const mapsRefreshInterval = time.Millisecond
+
+// procMaps caches the memory map of the process. It is read on first use and
+// reread when a lookup misses and the cache is older than mapsRefreshInterval,
+// since mappings come and go.
+var procMaps struct {
+	sync.Mutex
+	regions   []memRegion // sorted by start
+	raw       []byte      // contents of /proc/self/maps regions was parsed from
+	refreshed time.Time
+	reads     int // number of rereads, for tests
+	err       error
+}
+
+// IsAddrReadable reports whether the size bytes at addr are mapped readable,
+// according to /proc/self/maps.
+func IsAddrReadable(addr uintptr, size int) bool {
+	if addr == 0 || size <= 0 || addr+uintptr(size) < addr {
+		return false
+	}
+	end := addr + uintptr(size)
+
+	procMaps.Lock()
+	defer procMaps.Unlock()
+	if procMaps.regions != nil && readableIn(procMaps.regions, addr, end) {
+		return true
+	}
+	if time.Since(procMaps.refreshed) < mapsRefreshInterval || !refreshProcMaps() {
+		return false
+	}
+	return procMaps.err == nil && readableIn(procMaps.regions, addr, end)
+}
+
+// refreshProcMaps rereads /proc/self/maps and reports whether it changed, in
+// which case it is parsed again; procMaps must be locked.
+func refreshProcMaps() bool {
+	procMaps.refreshed = time.Now()
+	procMaps.reads++
+	data, err := ioutil.ReadFile("/proc/self/maps")
+	if err != nil {
+		procMaps.err = err
+		return true
+	}
+	if procMaps.err == nil && procMaps.regions != nil && bytes.Equal(data, procMaps.raw) {
+		return false
+	}
+	procMaps.raw = data
+	procMaps.regions, procMaps.err = parseProcMaps(data)
+	return true
+}
+
+func memoryRegions() ([]MemoryRegion, error) {
+	procMaps.Lock()
+	defer procMaps.Unlock()
+	refreshProcMaps()
+	if procMaps.err != nil {
+		return nil, procMaps.err
+	}
+	regions := make([]MemoryRegion, len(procMaps.regions))
+	for i, r := range procMaps.regions {
+		regions[i] = MemoryRegion{Start: r.start, End: r.end, Perms: r.perms, File: r.path}
+	}
+	return regions, nil
+}
+
+// readableIn reports whether [addr, end) is covered by adjacent readable
+// regions.
+func readableIn(regions []memRegion, addr, end uintptr) bool {
+	i := sort.Search(len(regions), func(i int) bool { return regions[i].end > addr })
+	for ; i < len(regions) && addr < end; i++ {
+		r := &regions[i]
+		if r.start > addr || !r.readable() {
+			return false
+		}
+		addr = r.end
+	}
+	return addr >= end
+}
+
+// parseProcMaps parses the contents of /proc/<pid>/maps.
+func parseProcMaps(data []byte) ([]memRegion, error) {
+	parsed, err := procmaps.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	regions := make([]memRegion, len(parsed))
+	for i, r := range parsed {
+		regions[i] = memRegion{start: uintptr(r.Start), end: uintptr(r.End), perms: r.Perms, path: r.Path}
+	}
+	return regions, nil
+}
+
+var errFault error = syscall.EFAULT
+
+// sysProcessVMReadv is the number of the process_vm_readv system call, which
+// package syscall does not define on every architecture.
+var sysProcessVMReadv = map[string]uintptr{
+	"386":      347,
+	"amd64":    310,
+	"arm":      376,
+	"arm64":    270,
+	"loong64":  270,
+	"mips":     4345,
+	"mipsle":   4345,
+	"mips64":   5304,
+	"mips64le": 5304,
+	"ppc64":    351,
+	"ppc64le":  351,
+	"riscv64":  270,
+	"s390x":    340,
+}[runtime.GOARCH]
+
+// vmReadvUnavailable is set once process_vm_readv turns out to be missing
+// (ENOSYS) or forbidden (EPERM, e.g. by a seccomp filter).
+var vmReadvUnavailable uint32
+
+type localIovec struct {
+	base unsafe.Pointer
+	len  uintptr
+}
+
+type remoteIovec struct {
+	base uintptr
+	len  uintptr
+}
+
+// SafeRead copies len(buf) bytes at addr into buf without risking a crash.
+// On Linux the memory is read with process_vm_readv on the own process, so an
+// unmapped or unreadable address yields syscall.EFAULT instead of a fault. If
+// the system call is unavailable, SafeRead falls back to checking the range
+// with IsAddrReadable and copying under debug.SetPanicOnFault.
+func SafeRead(addr uintptr, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	if sysProcessVMReadv == 0 || atomic.LoadUint32(&vmReadvUnavailable) != 0 {
+		return readWithRecover(addr, buf)
+	}
+	local := localIovec{unsafe.Pointer(&buf[0]), uintptr(len(buf))}
+	remote := remoteIovec{addr, uintptr(len(buf))}
+	n, _, errno := syscall.Syscall6(sysProcessVMReadv, uintptr(syscall.Getpid()),
+		uintptr(unsafe.Pointer(&local)), 1, uintptr(unsafe.Pointer(&remote)), 1, 0)
+	switch {
+	case errno == syscall.ENOSYS || errno == syscall.EPERM:
+		atomic.StoreUint32(&vmReadvUnavailable, 1)
+		return readWithRecover(addr, buf)
+	case errno != 0:
+		return errno
+	case int(n) < len(buf):
+		// Partial reads stop at the first unreadable page.
+		return syscall.EFAULT
+	}
+	return nil
+}
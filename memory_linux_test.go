@@ -0,0 +1,125 @@
+//go:build linux
+// +build linux
+
+package forceexport
+
+import (
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// expireProcMaps makes the next lookup miss reread /proc/self/maps.
+func expireProcMaps() {
+	procMaps.Lock()
+	procMaps.refreshed = time.Time{}
+	procMaps.Unlock()
+}
+
+func TestIsAddrReadable(t *testing.T) {
+	x := new([64]byte)
+	if !IsAddrReadable(uintptr(unsafe.Pointer(x)), len(x)) {
+		t.Error("Expected heap memory to be readable.")
+	}
+	if !IsAddrReadable(reflect.ValueOf(addOne).Pointer(), 16) {
+		t.Error("Expected code to be readable.")
+	}
+	if IsAddrReadable(0x1000, 8) || IsAddrReadable(uintptr(unsafe.Pointer(x)), 0) {
+		t.Error("Expected low addresses and empty ranges to be unreadable.")
+	}
+
+	page := syscall.Getpagesize()
+	mem, err := syscall.Mmap(-1, 0, 2*page, syscall.PROT_READ, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Munmap(mem)
+	if err := syscall.Mprotect(mem[page:], syscall.PROT_NONE); err != nil {
+		t.Fatal(err)
+	}
+	expireProcMaps()
+	addr := uintptr(unsafe.Pointer(&mem[0]))
+	if !IsAddrReadable(addr, page) {
+		t.Error("Expected a new readable mapping to be found.")
+	}
+	if IsAddrReadable(addr, page+1) || IsAddrReadable(addr+uintptr(page), 1) {
+		t.Error("Expected a PROT_NONE page to be unreadable.")
+	}
+}
+
+func TestParseProcMaps(t *testing.T) {
+	regions, err := parseProcMaps([]byte(`7ffd1000-7ffd2000 rw-p 00000000 00:00 0                          [stack]
+00400000-0048d000 r-xp 00000000 fd:01 1234                       /usr/bin/my prog
+0048d000-0048e000 ---p 00000000 00:00 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 3 || regions[0].start != 0x400000 || regions[0].path != "/usr/bin/my prog" || regions[2].path != "[stack]" {
+		t.Fatalf("Unexpected regions %+v.", regions)
+	}
+	if !readableIn(regions, 0x400000, 0x48d000) || readableIn(regions, 0x48c000, 0x48d001) || readableIn(regions, 0x3ff000, 0x400001) {
+		t.Error("Unexpected readability.")
+	}
+	if _, err := parseProcMaps([]byte("zz-10 r--p 0 0 0\n")); err == nil {
+		t.Error("Expected an error for a malformed line.")
+	}
+}
+
+func TestMemoryRegions(t *testing.T) {
+	regions, err := MemoryRegions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := reflect.ValueOf(addOne).Pointer()
+	var text, data bool
+	for i, r := range regions {
+		if i > 0 && r.Start < regions[i-1].End {
+			t.Errorf("Regions %v and %v overlap or are unsorted.", regions[i-1], r)
+		}
+		if r.Start <= pc && pc < r.End {
+			text = true
+			if r.Perms[2] != 'x' || !containsString(r.Sections, "text") {
+				t.Errorf("Expected %#x to lie in an executable text region, got %+v.", pc, r)
+			}
+		}
+		data = data || containsString(r.Sections, "data")
+	}
+	if !text || !data {
+		t.Errorf("Expected regions for text and data, got %+v.", regions)
+	}
+}
+
+func TestSafeRead(t *testing.T) {
+	x := []byte("forceexport")
+	buf := make([]byte, len(x))
+	if err := SafeRead(uintptr(unsafe.Pointer(&x[0])), buf); err != nil || string(buf) != "forceexport" {
+		t.Fatalf("Expected to read %q, got %q, %v.", x, buf, err)
+	}
+	if err := SafeRead(0x1000, buf); err != syscall.EFAULT {
+		t.Errorf("Expected EFAULT for an unmapped address, got %v.", err)
+	}
+
+	page := syscall.Getpagesize()
+	mem, err := syscall.Mmap(-1, 0, 2*page, syscall.PROT_READ, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Munmap(mem)
+	if err := syscall.Mprotect(mem[page:], syscall.PROT_NONE); err != nil {
+		t.Fatal(err)
+	}
+	if err := SafeRead(uintptr(unsafe.Pointer(&mem[page-4])), buf[:8]); err != syscall.EFAULT {
+		t.Errorf("Expected EFAULT for a read into a PROT_NONE page, got %v.", err)
+	}
+
+	// The fallback gives the same results.
+	if err := readWithRecover(uintptr(unsafe.Pointer(&x[0])), buf); err != nil || string(buf) != "forceexport" {
+		t.Errorf("Expected the fallback to read %q, got %q, %v.", x, buf, err)
+	}
+	if err := readWithRecover(uintptr(unsafe.Pointer(&mem[page-4])), buf[:8]); err != syscall.EFAULT {
+		t.Errorf("Expected the fallback to return EFAULT, got %v.", err)
+	}
+}
@@ -1,8 +1,12 @@
-//go:build linux || darwin || unix
+//go:build !linux && (darwin || unix)
+// +build !linux
+// +build darwin unix
 
 package forceexport
 
 import (
+	"fmt"
+	"runtime"
 	"syscall"
 	"unsafe"
 )
@@ -14,7 +18,7 @@ func IsAddrReadable(addr uintptr, size int) bool {
 	}
 
 	// Basic range check
-	if addr < 0x1000 || addr == 0xffffffffffffffff {
+	if addr < 0x1000 || addr == ^uintptr(0) {
 		return false
 	}
 
@@ -47,26 +51,43 @@ func IsAddrReadable(addr uintptr, size int) bool {
 
 // Conservative address check for Unix systems
 func conservativeUnixAddressCheck(addr uintptr, size int) bool {
+	a := uint64(addr) // the 64-bit bounds below overflow uintptr on 32-bit systems
 	if unsafe.Sizeof(uintptr(0)) == 8 { // 64-bit system
 		// Typical user space layout for Linux/Unix 64-bit systems
 		// Program segment usually starts near 0x400000
 		// Heap is usually at lower addresses, stack at higher addresses
 
 		// Check if in a reasonable program address range
-		if addr >= 0x400000 && addr <= 0x7fffffffffff {
+		if a >= 0x400000 && a <= 0x7fffffffffff {
 			return true
 		}
 
 		// Check if in a typical heap address range
-		if addr >= 0x1000000 && addr <= 0x40000000000 {
+		if a >= 0x1000000 && a <= 0x40000000000 {
 			return true
 		}
 	} else { // 32-bit system
 		// 32-bit systems have a smaller address space
-		if addr >= 0x8000 && addr <= 0x7fffffff {
+		if a >= 0x8000 && a <= 0x7fffffff {
 			return true
 		}
 	}
 
 	return false
 }
+
+func memoryRegions() ([]MemoryRegion, error) {
+	return nil, fmt.Errorf("MemoryRegions is not supported on %s", runtime.GOOS)
+}
+
+var errFault error = syscall.EFAULT
+
+// SafeRead copies len(buf) bytes at addr into buf, returning an error instead
+// of crashing if the memory is not readable. On this platform the range is
+// checked with IsAddrReadable and copied under debug.SetPanicOnFault.
+func SafeRead(addr uintptr, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	return readWithRecover(addr, buf)
+}
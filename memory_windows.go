@@ -1,8 +1,10 @@
 //go:build windows
+// +build windows
 
 package forceexport
 
 import (
+	"fmt"
 	"syscall"
 	"unsafe"
 )
@@ -11,12 +13,16 @@ var (
 	kernel32         = syscall.NewLazyDLL("kernel32.dll")
 	procVirtualQuery = kernel32.NewProc("VirtualQuery")
 	procIsBadReadPtr = kernel32.NewProc("IsBadReadPtr")
+
+	procGetMappedFileName = kernel32.NewProc("K32GetMappedFileNameW")
+	procReadProcessMemory = kernel32.NewProc("ReadProcessMemory")
 )
 
 const (
 	MEM_COMMIT    = 0x1000
 	PAGE_NOACCESS = 0x01
 	PAGE_GUARD    = 0x100
+	MEM_MAPPED    = 0x40000
 )
 
 type MEMORY_BASIC_INFORMATION struct {
@@ -36,7 +42,7 @@ func IsAddrReadable(addr uintptr, size int) bool {
 	}
 
 	// Basic range check
-	if addr < 0x10000 || addr == 0xffffffffffffffff {
+	if addr < 0x10000 || addr == ^uintptr(0) {
 		return false
 	}
 
@@ -74,3 +80,88 @@ func IsAddrReadable(addr uintptr, size int) bool {
 
 	return true
 }
+
+func memoryRegions() ([]MemoryRegion, error) {
+	var regions []MemoryRegion
+	var mbi MEMORY_BASIC_INFORMATION
+	for addr := uintptr(0); ; {
+		ret, _, _ := procVirtualQuery.Call(addr, uintptr(unsafe.Pointer(&mbi)), unsafe.Sizeof(mbi))
+		if ret == 0 || mbi.RegionSize == 0 {
+			break
+		}
+		if mbi.State == MEM_COMMIT {
+			regions = append(regions, MemoryRegion{
+				Start: mbi.BaseAddress,
+				End:   mbi.BaseAddress + mbi.RegionSize,
+				Perms: windowsPerms(mbi.Protect, mbi.Type),
+				File:  mappedFileName(mbi.BaseAddress),
+			})
+		}
+		next := mbi.BaseAddress + mbi.RegionSize
+		if next <= addr {
+			break
+		}
+		addr = next
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("VirtualQuery found no committed memory")
+	}
+	return regions, nil
+}
+
+// windowsPerms converts page protection flags to /proc/self/maps notation.
+func windowsPerms(protect, typ uint32) string {
+	perms := []byte("---p")
+	switch protect &^ (PAGE_GUARD | 0x200 | 0x400) { // guard, nocache, writecombine
+	case 0x02: // PAGE_READONLY
+		perms[0] = 'r'
+	case 0x04, 0x08: // PAGE_READWRITE, PAGE_WRITECOPY
+		perms[0], perms[1] = 'r', 'w'
+	case 0x10: // PAGE_EXECUTE
+		perms[2] = 'x'
+	case 0x20: // PAGE_EXECUTE_READ
+		perms[0], perms[2] = 'r', 'x'
+	case 0x40, 0x80: // PAGE_EXECUTE_READWRITE, PAGE_EXECUTE_WRITECOPY
+		perms[0], perms[1], perms[2] = 'r', 'w', 'x'
+	}
+	if typ == MEM_MAPPED {
+		perms[3] = 's'
+	}
+	return string(perms)
+}
+
+// mappedFileName returns the file mapped at addr, in NT device form, or "".
+func mappedFileName(addr uintptr) string {
+	if procGetMappedFileName.Find() != nil {
+		return ""
+	}
+	buf := make([]uint16, syscall.MAX_PATH)
+	process, _ := syscall.GetCurrentProcess()
+	n, _, _ := procGetMappedFileName.Call(uintptr(process), addr, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return ""
+	}
+	return syscall.UTF16ToString(buf[:n])
+}
+
+var errFault error = syscall.EFAULT
+
+// SafeRead copies len(buf) bytes at addr into buf without risking a crash: the
+// memory is read with ReadProcessMemory on the own process, which fails
+// instead of faulting on unreadable pages.
+func SafeRead(addr uintptr, buf []byte) error {
+	if len(buf) == 0 {
+		return nil
+	}
+	process, _ := syscall.GetCurrentProcess()
+	var n uintptr
+	ret, _, err := procReadProcessMemory.Call(uintptr(process), addr,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&n)))
+	if ret == 0 {
+		return err
+	}
+	if int(n) < len(buf) {
+		return errFault
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package forceexport
+
+// MemoryRegion is a mapped range of the process's address space.
+type MemoryRegion struct {
+	Start, End uintptr
+	Perms      string // in /proc/self/maps form, e.g. "r-xp" or "rw-p"
+	File       string // backing file or pseudo-path such as [heap]; empty if anonymous
+
+	// Sections names the module sections (see ModuleSection) that
+	// overlap the region, e.g. "text" or "noptrdata", "data".
+	Sections []string
+}
+
+// MemoryRegions returns the current memory map of the process, sorted by
+// address, with each region annotated with the module sections it overlaps.
+// It is built from /proc/self/maps on Linux and VirtualQuery on Windows and
+// not available elsewhere. If the modules cannot be found, the regions are
+// returned without sections.
+func MemoryRegions() ([]MemoryRegion, error) {
+	regions, err := memoryRegions()
+	if err != nil {
+		return nil, err
+	}
+	modules, err := Modules()
+	if err != nil {
+		return regions, nil
+	}
+	for i := range regions {
+		r := &regions[i]
+		for _, m := range modules {
+			for _, s := range m.Sections {
+				if s.Start < s.End && s.Start < r.End && r.Start < s.End && !containsString(r.Sections, s.Name) {
+					r.Sections = append(r.Sections, s.Name)
+				}
+			}
+		}
+	}
+	return regions, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
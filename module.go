@@ -0,0 +1,122 @@
+package forceexport
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Module describes one module of the running program: the main executable or
+// a plugin loaded with plugin.Open. Fields the runtime of the Go version in
+// use does not record are left empty.
+type Module struct {
+	Name       string // moduledata.modulename; empty for the main executable
+	PluginPath string // import path of the plugin; empty for the main executable
+	HasMain    bool   // whether the module contains the main function
+
+	// Text and EText delimit the module's text segment; MinPC and MaxPC
+	// the range covered by its function table.
+	Text, EText  uintptr
+	MinPC, MaxPC uintptr
+
+	// ModuleHashes are the ABI hashes of the shared libraries this module
+	// was linked against; PkgHashes those of the packages in a plugin.
+	ModuleHashes []ModuleHash
+	PkgHashes    []ModuleHash
+
+	// Sections are the bounds of the module's text, data and bss sections
+	// and of its type data.
+	Sections []ModuleSection
+
+	wrapper moduleWrapper
+}
+
+// ModuleSection is a range of a module named after its moduledata fields:
+// text, noptrdata, data, bss, noptrbss or types.
+type ModuleSection struct {
+	Name       string
+	Start, End uintptr
+}
+
+// ModuleHash is the ABI hash of a module or package, as recorded at link time
+// and as found in the running program.
+type ModuleHash struct {
+	ModuleName   string
+	LinktimeHash string
+	RuntimeHash  string
+}
+
+// String returns a short description of the module.
+func (me *Module) String() string {
+	name := me.PluginPath
+	if name == "" {
+		name = me.Name
+	}
+	if name == "" {
+		name = "main"
+	}
+	return fmt.Sprintf("%s [%#x-%#x]", name, me.Text, me.EText)
+}
+
+// moduleCache holds the Module values handed out so far. Modules are only
+// ever appended to the runtime's list (plugins cannot be unloaded), so the
+// cache is extended whenever the chain turns out to be longer than before.
+var moduleCache struct {
+	sync.Mutex
+	modules []*Module
+}
+
+// Modules returns the modules of the running program: the main executable
+// first, followed by plugins in the order they were loaded. Plugins opened
+// after a previous call are picked up automatically.
+func Modules() ([]*Module, error) {
+	first := getModuleWrapper()
+	if first == nil {
+		return nil, fmt.Errorf("moduledata not found!")
+	}
+
+	moduleCache.Lock()
+	defer moduleCache.Unlock()
+
+	i := 0
+	for w := first; w != nil; w = w.GetNext() {
+		if i < len(moduleCache.modules) && moduleCache.modules[i].wrapper == w {
+			i++
+			continue
+		}
+		// Either the chain grew or the cache no longer matches it (e.g.
+		// the moduledata address was only just discovered); rebuild the
+		// tail from here.
+		moduleCache.modules = moduleCache.modules[:i]
+		m := w.GetInfo()
+		m.wrapper = w
+		moduleCache.modules = append(moduleCache.modules, &m)
+		i++
+	}
+	moduleCache.modules = moduleCache.modules[:i]
+
+	modules := make([]*Module, len(moduleCache.modules))
+	copy(modules, moduleCache.modules)
+	return modules, nil
+}
+
+// FindFuncInModule is like FindFuncWithName but only searches the given
+// module, as returned by Modules. This makes it possible to pick a function
+// from a specific plugin when several modules define the same name.
+func FindFuncInModule(module *Module, name string) (uintptr, error) {
+	if module == nil || module.wrapper == nil {
+		return 0, fmt.Errorf("invalid module")
+	}
+	var match funcMatch
+	forEachFuncInModule(module.wrapper, func(f *runtime.Func) bool {
+		if f.Name() == name {
+			return !match.add(f)
+		}
+		return true
+	})
+	if match.rank == 0 {
+		return 0, fmt.Errorf("Invalid function name: %s in module %s", name, module)
+	}
+	config := currentConfig()
+	return match.result(name, &config)
+}
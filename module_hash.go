@@ -0,0 +1,18 @@
+//go:build go1.16
+// +build go1.16
+
+package forceexport
+
+func moduleHashes(hashes []modulehash) []ModuleHash {
+	if len(hashes) == 0 {
+		return nil
+	}
+	out := make([]ModuleHash, len(hashes))
+	for i, h := range hashes {
+		out[i] = ModuleHash{ModuleName: h.modulename, LinktimeHash: h.linktimehash}
+		if h.runtimehash != nil {
+			out[i].RuntimeHash = *h.runtimehash
+		}
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+package forceexport
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// The runtime encodes per-pc information (line, file, stack pointer delta,
+// ...) as tables of varint pairs: a zig-zag encoded value delta followed by a
+// pc delta in units of the instruction quantum. A function's tables start at
+// offsets recorded in its _func into the module's pctab (pclntable before Go
+// 1.16).
+
+// PCRange is one entry of a pc-value table: Value applies to the pcs in
+// [Start, End).
+type PCRange struct {
+	Start, End uintptr
+	Value      int32
+}
+
+// PCFile is one entry of a function's pc-file table: the instructions in
+// [Start, End) come from File.
+type PCFile struct {
+	Start, End uintptr
+	File       string
+}
+
+var errCorruptPCTable = errors.New("corrupt pc-value table")
+
+// pcQuantum is the minimal instruction size, by which pc deltas are scaled.
+var pcQuantum = func() uintptr {
+	switch runtime.GOARCH {
+	case "386", "amd64", "wasm":
+		return 1
+	case "s390x":
+		return 2
+	}
+	return 4
+}()
+
+// LineTable decodes the function's pc-line table. Lines of inlined calls are
+// those of the inlined function, as in runtime.Func.FileLine.
+func (me *FuncInfo) LineTable() ([]PCRange, error) {
+	module, err := me.pcModule()
+	if err != nil {
+		return nil, err
+	}
+	return decodePCTable(module.GetPCTab(), uint32(me.raw.pcln), me.Entry, pcQuantum)
+}
+
+// SPDeltaTable decodes the function's pc-SP-delta table: the size of the
+// frame allocated so far at each pc.
+func (me *FuncInfo) SPDeltaTable() ([]PCRange, error) {
+	module, err := me.pcModule()
+	if err != nil {
+		return nil, err
+	}
+	return decodePCTable(module.GetPCTab(), uint32(me.raw.pcsp), me.Entry, pcQuantum)
+}
+
+// FileTable decodes the function's pc-file table, resolving file numbers to
+// names.
+func (me *FuncInfo) FileTable() ([]PCFile, error) {
+	module, err := me.pcModule()
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := decodePCTable(module.GetPCTab(), uint32(me.raw.pcfile), me.Entry, pcQuantum)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]PCFile, len(ranges))
+	for i, r := range ranges {
+		files[i] = PCFile{Start: r.Start, End: r.End, File: module.GetFuncFile(me.raw, r.Value)}
+	}
+	return files, nil
+}
+
+// pcModule returns the module holding the function's tables.
+func (me *FuncInfo) pcModule() (moduleWrapper, error) {
+	if me.raw == nil {
+		return nil, fmt.Errorf("no function table entry for %s", me.Name)
+	}
+	module := moduleForPC(me.Entry)
+	if module == nil {
+		return nil, fmt.Errorf("no module contains %s at %#x", me.Name, me.Entry)
+	}
+	return module, nil
+}
+
+// moduleForPC returns the module whose function table covers pc.
+func moduleForPC(pc uintptr) moduleWrapper {
+	for w := getModuleWrapper(); w != nil; w = w.GetNext() {
+		if info := w.GetInfo(); info.MinPC <= pc && pc < info.MaxPC {
+			return w
+		}
+	}
+	return nil
+}
+
+// decodePCTable decodes the pc-value table at off in tab for a function
+// starting at entry. An offset of 0 means the function has no such table.
+func decodePCTable(tab []byte, off uint32, entry, quantum uintptr) ([]PCRange, error) {
+	if off == 0 {
+		return nil, nil
+	}
+	if int(off) >= len(tab) {
+		return nil, errCorruptPCTable
+	}
+	p := tab[off:]
+	pc, val := entry, int32(-1)
+	var ranges []PCRange
+	for first := true; ; first = false {
+		uvdelta, n := readVarint(p)
+		if n == 0 {
+			return nil, errCorruptPCTable
+		}
+		if uvdelta == 0 && !first {
+			return ranges, nil
+		}
+		p = p[n:]
+		val += int32(-(uvdelta & 1) ^ (uvdelta >> 1))
+		pcdelta, n := readVarint(p)
+		if n == 0 {
+			return nil, errCorruptPCTable
+		}
+		p = p[n:]
+		start := pc
+		pc += uintptr(pcdelta) * quantum
+		ranges = append(ranges, PCRange{Start: start, End: pc, Value: val})
+	}
+}
+
+// readVarint reads an unsigned varint from p and returns it with the number
+// of bytes read, or 0 if p ends in the middle of it.
+func readVarint(p []byte) (uint32, int) {
+	var v, shift uint32
+	for i, b := range p {
+		v |= uint32(b&0x7F) << (shift & 31)
+		if b&0x80 == 0 {
+			return v, i + 1
+		}
+		shift += 7
+	}
+	return 0, 0
+}
+
+// cString returns the NUL-terminated string at off in b.
+func cString(b []byte, off uint32) string {
+	if int(off) >= len(b) {
+		return "?"
+	}
+	s := b[off:]
+	for i, c := range s {
+		if c == 0 {
+			return string(s[:i])
+		}
+	}
+	return string(s)
+}
@@ -0,0 +1,71 @@
+package forceexport
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPCTables(t *testing.T) {
+	info, err := FindFuncInfo("github.com/szmcdull/go-forceexport.withClosures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := runtime.FuncForPC(info.Entry)
+
+	lines, err := info.LineTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) == 0 || lines[0].Start != info.Entry {
+		t.Fatalf("Expected a line table starting at %#x, got %v.", info.Entry, lines)
+	}
+	for i, r := range lines {
+		if r.End <= r.Start || (i > 0 && r.Start != lines[i-1].End) {
+			t.Errorf("Ranges should be contiguous, got %v.", lines)
+		}
+		if _, line := f.FileLine(r.Start); int32(line) != r.Value {
+			t.Errorf("Expected line %d at %#x, got %d.", line, r.Start, r.Value)
+		}
+	}
+
+	files, err := info.FileTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("Expected a file table.")
+	}
+	for _, r := range files {
+		if file, _ := f.FileLine(r.Start); file != r.File {
+			t.Errorf("Expected file %s at %#x, got %s.", file, r.Start, r.File)
+		}
+	}
+	if !strings.HasSuffix(files[0].File, "funcinfo_test.go") {
+		t.Errorf("Unexpected file %s.", files[0].File)
+	}
+
+	sp, err := info.SPDeltaTable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sp) == 0 || sp[0].Value != 0 || sp[len(sp)-1].End != lines[len(lines)-1].End {
+		t.Errorf("Unexpected SP delta table %v.", sp)
+	}
+}
+
+func TestDecodePCTable(t *testing.T) {
+	// Value +1 (zig-zag 2) for 3 quanta, +200 (zig-zag 400) for 1 quantum.
+	tab := []byte{0xFF, 2, 3, 0x90, 0x03, 1, 0}
+	ranges, err := decodePCTable(tab, 1, 0x1000, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PCRange{{0x1000, 0x100c, 0}, {0x100c, 0x1010, 200}}
+	if len(ranges) != len(want) || ranges[0] != want[0] || ranges[1] != want[1] {
+		t.Errorf("Expected %v, got %v.", want, ranges)
+	}
+	if _, err := decodePCTable(tab[:5], 1, 0x1000, 4); err == nil {
+		t.Error("Expected an error for a truncated table.")
+	}
+}
@@ -0,0 +1,73 @@
+//go:build go1.16 && (linux || darwin) && cgo
+// +build go1.16
+// +build linux darwin
+// +build cgo
+
+package forceexport
+
+import (
+	"os/exec"
+	"path/filepath"
+	"plugin"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPluginModules(t *testing.T) {
+	before, err := Modules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	so := filepath.Join(t.TempDir(), "addtwo.so")
+	// The plugin must be built by exactly the same toolchain as the test.
+	goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+	cmd := exec.Command(goCmd, "build", "-buildmode=plugin", "-o", so, "./testdata/plugin")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(out), "-buildmode=plugin not supported") {
+			t.Skipf("plugins are not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+		}
+		t.Fatalf("cannot build plugin: %v\n%s", err, out)
+	}
+	if _, err := plugin.Open(so); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := Modules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before)+1 {
+		t.Fatalf("Expected one more module after plugin.Open, got %d -> %d.", len(before), len(after))
+	}
+	if after[0] != before[0] {
+		t.Error("Expected the cached main module to be kept.")
+	}
+	p := after[len(after)-1]
+	if p.PluginPath == "" || p.Text == 0 || p.EText <= p.Text {
+		t.Errorf("Unexpected plugin module %+v.", p)
+	}
+
+	name := p.PluginPath + ".addTwo"
+	if _, err := FindFuncInModule(after[0], name); err == nil {
+		t.Errorf("Expected %s not to be found in the main module.", name)
+	}
+	codePtr, err := FindFuncInModule(p, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if codePtr < p.Text || codePtr >= p.EText {
+		t.Errorf("Expected %#x to be inside the plugin's text.", codePtr)
+	}
+	var addTwo func(int) int
+	CreateFuncForCodePtr(&addTwo, codePtr)
+	if addTwo(3) != 5 {
+		t.Error("Expected addTwo to add two to 3.")
+	}
+
+	// The global lookup sees the plugin too.
+	if err := GetFunc(&addTwo, name); err != nil {
+		t.Error(err)
+	}
+}
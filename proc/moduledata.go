@@ -0,0 +1,209 @@
+package proc
+
+import (
+	"debug/elf"
+	"fmt"
+
+	"github.com/szmcdull/go-forceexport/internal/pclntab"
+	"github.com/szmcdull/go-forceexport/internal/procmaps"
+)
+
+// Word indexes of moduledata fields. Since Go 1.16 moduledata starts with
+// pcHeader *pcHeader, followed by five slices into the function table,
+// ftab []functab, findfunctab uintptr and minpc, maxpc uintptr; before, with
+// pclntable []byte, ftab []functab, filetab []uint32, findfunctab uintptr and
+// minpc, maxpc uintptr.
+const (
+	mdPclntable = 13
+	mdMinPC     = 20
+	mdWords     = 22
+
+	mdMinPCGo12 = 10
+)
+
+// maxTableSize bounds the size of a function table.
+const maxTableSize = 1 << 31
+
+// pcQuantum returns the instruction size quantum the function table of an
+// executable for machine records (sys.PCQuantum of its architecture).
+func pcQuantum(machine elf.Machine) int {
+	switch machine {
+	case elf.EM_386, elf.EM_X86_64:
+		return 1
+	case elf.EM_S390:
+		return 2
+	}
+	return 4
+}
+
+// findModuleData locates the first moduledata of the process, through the
+// runtime.firstmoduledata symbol or, in stripped executables, by scanning.
+func (me *Process) findModuleData(maps, exeMaps []procmaps.Region) error {
+	if syms, err := me.exe.Symbols(); err == nil {
+		for _, sym := range syms {
+			if sym.Name == "runtime.firstmoduledata" {
+				me.moduleData = sym.Value + me.bias
+				return nil
+			}
+		}
+	}
+	header, err := me.findPCHeader(exeMaps)
+	if err != nil {
+		return err
+	}
+	me.moduleData, err = me.scanModuleData(header, exeMaps, dataMappings(maps, exeMaps))
+	return err
+}
+
+// findPCHeader returns the address of the function table, from its section or,
+// if the executable has no section headers, by searching the read-only
+// mappings of the executable for the table's header.
+func (me *Process) findPCHeader(exeMaps []procmaps.Region) (uint64, error) {
+	for _, name := range []string{".gopclntab", ".data.rel.ro.gopclntab"} {
+		if s := me.exe.Section(name); s != nil {
+			return s.Addr + me.bias, nil
+		}
+	}
+	for _, m := range exeMaps {
+		if !m.Readable() || m.Writable() {
+			continue
+		}
+		buf := make([]byte, m.End-m.Start)
+		if err := me.ReadMemory(m.Start, buf); err != nil {
+			continue
+		}
+		for off := 0; off+pclntab.HeaderSize <= len(buf); off += 8 {
+			if me.isPCHeader(buf[off:off+pclntab.HeaderSize], pclntab.MaxFuncs) {
+				return m.Start + uint64(off), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot find the function table")
+}
+
+// isPCHeader reports whether b starts with the header of a function table of
+// this process with at most maxFuncs functions, by the rules forceexport
+// applies to its own pcHeader.
+func (me *Process) isPCHeader(b []byte, maxFuncs uint64) bool {
+	header, ok := pclntab.ParseHeader(b, me.byteOrder)
+	return ok && header.Check(me.ptrSize, pcQuantum(me.exe.Machine), maxFuncs)
+}
+
+// dataMappings returns the writable mappings of the executable and the
+// anonymous mapping following them, which holds its bss.
+func dataMappings(maps, exeMaps []procmaps.Region) []procmaps.Region {
+	var data []procmaps.Region
+	for i, m := range maps {
+		if !m.Writable() {
+			continue
+		}
+		switch {
+		case m.Path == exeMaps[0].Path:
+			data = append(data, m)
+		case m.Path == "" && i > 0 && maps[i-1].Path == exeMaps[0].Path && maps[i-1].End == m.Start:
+			data = append(data, m)
+		}
+	}
+	return data
+}
+
+// scanModuleData searches the data mappings for a moduledata whose first word
+// points at the function table at header.
+//
+// This differs from the search forceexport does in its own process, which
+// probes every word near its code because it cannot tell where its pcHeader
+// is. Here the executable's sections or read-only mappings give the pcHeader
+// address, so only words holding exactly that pointer are candidates. Each
+// candidate is then checked like in the own process: the header by the same
+// rules (magic, ptrSize and minLC of the target, function count), and the
+// moduledata's [minpc, maxpc) must lie in the executable's code, which
+// stands in for the check that it holds the running runtime.
+func (me *Process) scanModuleData(header uint64, exeMaps, dataMaps []procmaps.Region) (uint64, error) {
+	for _, m := range dataMaps {
+		buf := make([]byte, m.End-m.Start)
+		if err := me.ReadMemory(m.Start, buf); err != nil {
+			continue
+		}
+		for off := 0; off+me.ptrSize <= len(buf); off += me.ptrSize {
+			if me.word(buf[off:]) != header {
+				continue
+			}
+			addr := m.Start + uint64(off)
+			if _, _, err := me.tableRange(addr, exeMaps); err == nil {
+				return addr, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot find the moduledata")
+}
+
+// tableRange returns the address and size of the function table described by
+// the moduledata at addr. If exeMaps is not nil, the moduledata is also
+// checked to be a plausible first moduledata of the executable.
+func (me *Process) tableRange(addr uint64, exeMaps []procmaps.Region) (start, size uint64, err error) {
+	buf := make([]byte, mdWords*me.ptrSize)
+	if err := me.ReadMemory(addr, buf); err != nil {
+		return 0, 0, err
+	}
+	w := func(i int) uint64 {
+		return me.word(buf[i*me.ptrSize:])
+	}
+	start = w(0)
+	header := make([]byte, pclntab.HeaderSize)
+	maxFuncs := ^uint64(0)
+	if exeMaps != nil {
+		maxFuncs = pclntab.MaxFuncs
+	}
+	if err := me.ReadMemory(start, header); err != nil || !me.isPCHeader(header, maxFuncs) {
+		return 0, 0, fmt.Errorf("moduledata at %#x does not point to a function table", addr)
+	}
+
+	var minpc, maxpc uint64
+	if me.byteOrder.Uint32(header) == pclntab.Go12Magic {
+		size = w(1)
+		minpc, maxpc = w(mdMinPCGo12), w(mdMinPCGo12+1)
+	} else {
+		if pclntable := w(mdPclntable); pclntable > start {
+			size = pclntable + w(mdPclntable+1) - start
+		}
+		minpc, maxpc = w(mdMinPC), w(mdMinPC+1)
+	}
+	if size == 0 || size > maxTableSize || minpc >= maxpc || exeMaps != nil && !inCode(exeMaps, minpc, maxpc) {
+		return 0, 0, fmt.Errorf("moduledata at %#x is corrupt", addr)
+	}
+	return start, size, nil
+}
+
+// inCode reports whether [start, end) lies in an executable mapping.
+func inCode(maps []procmaps.Region, start, end uint64) bool {
+	for _, m := range maps {
+		if len(m.Perms) > 2 && m.Perms[2] == 'x' && m.Start <= start && end <= m.End {
+			return true
+		}
+	}
+	return false
+}
+
+// loadTable reads the function table of the moduledata and decodes it.
+func (me *Process) loadTable() error {
+	start, size, err := me.tableRange(me.moduleData, nil)
+	if err != nil {
+		return err
+	}
+	data := make([]byte, size)
+	if err := me.ReadMemory(start, data); err != nil {
+		return err
+	}
+	var textStart uint64
+	if s := me.exe.Section(".text"); s != nil {
+		textStart = s.Addr + me.bias
+	}
+	if me.table, err = pclntab.NewTable(data, textStart); err != nil {
+		return err
+	}
+	me.funcs = make([]Func, len(me.table.Funcs))
+	for i, fn := range me.table.Funcs {
+		me.funcs[i] = Func{Name: fn.Name, Entry: fn.Entry, End: fn.End}
+	}
+	return nil
+}
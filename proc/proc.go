@@ -0,0 +1,166 @@
+// Package proc inspects another running Go process without stopping it: it
+// lists the process's functions, resolves program counters to functions and
+// source lines, and reads global variables. Everything is read from the
+// process's memory, so the process is neither stopped nor modified; memory may
+// change while it is being read.
+//
+// The function table is found through the target's first moduledata, located
+// through the runtime.firstmoduledata symbol of its executable or, for
+// stripped binaries, by scanning its writable memory for a moduledata pointing
+// at the table. Unlike forceexport searching its own memory since Go 1.23, the
+// table's address is known from the executable here, so the scan only looks
+// for pointers to it; candidates are checked by the same rules. Global
+// variables need the symbol table and are not available in binaries linked
+// with -ldflags=-s.
+//
+// Attaching is only supported on Linux, where it needs the same permissions as
+// ptrace: the same user and a permissive kernel.yama.ptrace_scope, or
+// CAP_SYS_PTRACE. The target's function table is decoded with debug/gosym, so
+// the inspecting program must be built with a Go version at least as new as
+// the target's.
+package proc
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/szmcdull/go-forceexport/internal/symname"
+)
+
+// Process is an attached Go process.
+type Process struct {
+	Pid int
+
+	mem       memReader
+	exe       *elf.File
+	bias      uint64 // load address minus link address, for PIE executables
+	ptrSize   int
+	byteOrder binary.ByteOrder
+
+	moduleData uint64
+	table      *gosym.Table
+	funcs      []Func
+}
+
+// Func describes a function of the process.
+type Func struct {
+	Name  string
+	Entry uint64 // address of the first instruction
+	End   uint64 // address just past the last instruction
+}
+
+// Var describes a global variable of the process.
+type Var struct {
+	Name string
+	Addr uint64
+	Size uint64
+}
+
+// memReader reads the memory of the process.
+type memReader interface {
+	ReadAt(buf []byte, off int64) (int, error)
+	Close() error
+}
+
+// Close releases the resources held for the process. The process itself is
+// not affected.
+func (me *Process) Close() error {
+	me.exe.Close()
+	return me.mem.Close()
+}
+
+// ModuleData returns the address of the process's first moduledata.
+func (me *Process) ModuleData() uint64 {
+	return me.moduleData
+}
+
+// Funcs returns all functions of the process, sorted by entry address.
+func (me *Process) Funcs() []Func {
+	return me.funcs
+}
+
+// FindFunc returns the function with the given name, in the form accepted by
+// forceexport.GetFunc.
+func (me *Process) FindFunc(name string) (*Func, error) {
+	fn := me.table.LookupFunc(symname.Mangle(name))
+	if fn == nil {
+		return nil, fmt.Errorf("Invalid function name: %s", name)
+	}
+	return &Func{Name: fn.Name, Entry: fn.Entry, End: fn.End}, nil
+}
+
+// FuncForPC returns the function containing pc, or nil.
+func (me *Process) FuncForPC(pc uint64) *Func {
+	fn := me.table.PCToFunc(pc)
+	if fn == nil {
+		return nil
+	}
+	return &Func{Name: fn.Name, Entry: fn.Entry, End: fn.End}
+}
+
+// FileLine returns the source file and line of pc, or "" and 0 if pc is not in
+// a function.
+func (me *Process) FileLine(pc uint64) (file string, line int) {
+	file, line, _ = me.table.PCToLine(pc)
+	return file, line
+}
+
+// LookupVar returns the global variable with the given name, e.g.
+// "net/http.DefaultClient".
+func (me *Process) LookupVar(name string) (*Var, error) {
+	syms, err := me.exe.Symbols()
+	if err != nil {
+		return nil, fmt.Errorf("cannot look up %s: %v", name, err)
+	}
+	for _, sym := range syms {
+		if sym.Name == name && elf.ST_TYPE(sym.Info) == elf.STT_OBJECT {
+			return &Var{Name: name, Addr: sym.Value + me.bias, Size: sym.Size}, nil
+		}
+	}
+	return nil, fmt.Errorf("Invalid variable name: %s", name)
+}
+
+// ReadVar returns the current contents of the named global variable.
+func (me *Process) ReadVar(name string) ([]byte, error) {
+	v, err := me.LookupVar(name)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, v.Size)
+	if err := me.ReadMemory(v.Addr, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ReadMemory copies len(buf) bytes at addr in the process into buf.
+func (me *Process) ReadMemory(addr uint64, buf []byte) error {
+	n, err := me.mem.ReadAt(buf, int64(addr))
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil {
+		err = fmt.Errorf("short read")
+	}
+	return fmt.Errorf("cannot read %d bytes at %#x: %v", len(buf), addr, err)
+}
+
+// ReadPointer reads a pointer-sized word at addr in the process, e.g. to
+// follow a pointer read from a variable.
+func (me *Process) ReadPointer(addr uint64) (uint64, error) {
+	buf := make([]byte, me.ptrSize)
+	if err := me.ReadMemory(addr, buf); err != nil {
+		return 0, err
+	}
+	return me.word(buf), nil
+}
+
+// word decodes a pointer-sized word of the process.
+func (me *Process) word(b []byte) uint64 {
+	if me.ptrSize == 4 {
+		return uint64(me.byteOrder.Uint32(b))
+	}
+	return me.byteOrder.Uint64(b)
+}
@@ -0,0 +1,89 @@
+//go:build linux
+// +build linux
+
+package proc
+
+import (
+	"debug/elf"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/szmcdull/go-forceexport/internal/procmaps"
+)
+
+// Attach opens the process with the given pid for inspection. Its memory is
+// read through /proc/<pid>/mem and its executable through /proc/<pid>/exe.
+func Attach(pid int) (*Process, error) {
+	dir := "/proc/" + strconv.Itoa(pid)
+	exePath, err := os.Readlink(dir + "/exe")
+	if err != nil {
+		return nil, err
+	}
+	exe, err := elf.Open(dir + "/exe")
+	if err != nil {
+		return nil, err
+	}
+	mem, err := os.Open(dir + "/mem")
+	if err != nil {
+		exe.Close()
+		return nil, err
+	}
+	p := &Process{Pid: pid, mem: mem, exe: exe, byteOrder: exe.ByteOrder, ptrSize: 8}
+	if exe.Class == elf.ELFCLASS32 {
+		p.ptrSize = 4
+	}
+
+	if err := p.init(dir, exePath); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("process %d: %v", pid, err)
+	}
+	return p, nil
+}
+
+func (me *Process) init(dir, exePath string) error {
+	data, err := ioutil.ReadFile(dir + "/maps")
+	if err != nil {
+		return err
+	}
+	maps, err := procmaps.Parse(data)
+	if err != nil {
+		return err
+	}
+	var exeMaps []procmaps.Region
+	for _, m := range maps {
+		if m.Path == exePath {
+			exeMaps = append(exeMaps, m)
+		}
+	}
+	if len(exeMaps) == 0 {
+		return fmt.Errorf("executable %s is not mapped", exePath)
+	}
+	if me.exe.Type == elf.ET_DYN {
+		if me.bias, err = loadBias(me.exe, exeMaps); err != nil {
+			return err
+		}
+	}
+	if err := me.findModuleData(maps, exeMaps); err != nil {
+		return err
+	}
+	return me.loadTable()
+}
+
+// loadBias returns the difference between the load and link addresses of a
+// position-independent executable: the mapping of its first page is at the
+// address of the segment loaded from file offset 0.
+func loadBias(exe *elf.File, exeMaps []procmaps.Region) (uint64, error) {
+	for _, prog := range exe.Progs {
+		if prog.Type != elf.PT_LOAD || prog.Off != 0 {
+			continue
+		}
+		for _, m := range exeMaps {
+			if m.Offset == 0 {
+				return m.Start - prog.Vaddr, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("cannot find the load address of the executable")
+}
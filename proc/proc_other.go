@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package proc
+
+import (
+	"errors"
+)
+
+// Attach is only supported on Linux.
+func Attach(pid int) (*Process, error) {
+	return nil, errors.New("attaching to a process is not supported on this platform")
+}
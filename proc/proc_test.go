@@ -0,0 +1,152 @@
+//go:build go1.16 && linux
+// +build go1.16,linux
+
+package proc
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/szmcdull/go-forceexport/internal/procmaps"
+)
+
+// startTarget builds and starts testdata/target and returns the process and
+// the address of its main.work, as printed by it.
+func startTarget(t *testing.T, args ...string) (*exec.Cmd, uint64) {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "target")
+	goCmd := filepath.Join(runtime.GOROOT(), "bin", "go")
+	build := exec.Command(goCmd, append(append([]string{"build", "-o", exe}, args...), "./testdata/target")...)
+	if out, err := build.CombinedOutput(); err != nil {
+		if len(args) > 0 {
+			// e.g. -buildmode=pie without cgo on some platforms.
+			t.Skipf("cannot build target: %v\n%s", err, out)
+		}
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+
+	cmd := exec.Command(exe)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		stdin.Close()
+		cmd.Wait()
+	})
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	work, err := strconv.ParseUint(strings.TrimSpace(line), 0, 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd, work
+}
+
+func TestAttach(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		args    []string
+		symbols bool
+	}{
+		{"exe", nil, true},
+		{"pie", []string{"-buildmode=pie"}, true},
+		{"stripped", []string{"-ldflags=-s -w"}, false},
+		{"stripped-pie", []string{"-buildmode=pie", "-ldflags=-s -w"}, false},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			cmd, work := startTarget(t, test.args...)
+			p, err := Attach(cmd.Process.Pid)
+			if err != nil {
+				if os.IsPermission(err) {
+					t.Skip(err)
+				}
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			fn, err := p.FindFunc("main.work")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fn.Entry != work {
+				t.Errorf("main.work at %#x, want %#x", fn.Entry, work)
+			}
+			if f := p.FuncForPC(work + 1); f == nil || f.Name != "main.work" {
+				t.Errorf("FuncForPC(%#x) = %v, want main.work", work+1, f)
+			}
+			if file, line := p.FileLine(work); !strings.HasSuffix(file, "target/main.go") || line == 0 {
+				t.Errorf("FileLine(%#x) = %s:%d, want target/main.go", work, file, line)
+			}
+			if _, err := p.FindFunc("main.nothere"); err == nil {
+				t.Error("FindFunc found main.nothere")
+			}
+			found := false
+			for _, f := range p.Funcs() {
+				found = found || f.Name == "runtime.mallocgc"
+			}
+			if !found {
+				t.Error("Funcs lacks runtime.mallocgc")
+			}
+
+			b, err := p.ReadVar("main.counter")
+			if !test.symbols {
+				if err == nil {
+					t.Error("ReadVar succeeded without a symbol table")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.byteOrder.Uint64(b); got != 0x1122334455667789 {
+				t.Errorf("main.counter = %#x, want 0x1122334455667789", got)
+			}
+
+			// The scan must find the moduledata the symbol points at.
+			data, err := os.ReadFile("/proc/" + strconv.Itoa(p.Pid) + "/maps")
+			if err != nil {
+				t.Fatal(err)
+			}
+			maps, err := procmaps.Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			exePath, _ := os.Readlink("/proc/" + strconv.Itoa(p.Pid) + "/exe")
+			var exeMaps []procmaps.Region
+			for _, m := range maps {
+				if m.Path == exePath {
+					exeMaps = append(exeMaps, m)
+				}
+			}
+			header, err := p.findPCHeader(exeMaps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			md, err := p.scanModuleData(header, exeMaps, dataMappings(maps, exeMaps))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if md != p.ModuleData() {
+				t.Errorf("scan found moduledata at %#x, symbol at %#x", md, p.ModuleData())
+			}
+		})
+	}
+}
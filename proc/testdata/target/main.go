@@ -0,0 +1,23 @@
+// Command target is inspected by the proc tests: it prints the address of
+// main.work and waits for its standard input to be closed.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+)
+
+var counter uint64 = 0x1122334455667788
+
+//go:noinline
+func work() {
+	counter++
+}
+
+func main() {
+	work()
+	fmt.Printf("%#x\n", reflect.ValueOf(work).Pointer())
+	ioutil.ReadAll(os.Stdin)
+}
@@ -0,0 +1,153 @@
+package forceexport
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// FaultError reports a memory fault in a function called through SafeCall or
+// a Guarded function, typically because the declared signature does not match
+// the real one.
+type FaultError struct {
+	Addr   uintptr // faulting address, or 0 if the runtime did not record it
+	Symbol string  // name of the called function
+	Err    error   // the runtime's error
+}
+
+func (me *FaultError) Error() string {
+	return fmt.Sprintf("fault at address %#x in %s: %v", me.Addr, me.Symbol, me.Err)
+}
+
+func (me *FaultError) Unwrap() error {
+	return me.Err
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Guarded makes GetFunc wrap the function so that memory faults during a call
+// are turned into a *FaultError, as SafeCall does. If the function's last
+// result is an error, the fault is returned there with zero values for the
+// other results; otherwise the call panics with the *FaultError, which the
+// caller can recover. Guarded calls go through reflect and are much slower.
+func Guarded() Option {
+	return func(o *getFuncOptions) {
+		o.guarded = true
+	}
+}
+
+// SafeCall calls fn, which must be a function, with args and returns its
+// results. A memory fault during the call (e.g. because fn was forced with the
+// wrong signature) is returned as a *FaultError instead of crashing the
+// process; other panics are propagated. Nil args stand for the zero value of
+// the corresponding parameter.
+//
+// Faults are caught with debug.SetPanicOnFault, which only works for faults in
+// Go code: a fault inside the runtime, or corrupted memory that is only used
+// later, can still bring the process down.
+func SafeCall(fn interface{}, args ...interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("SafeCall: fn must be a non-nil function")
+	}
+	t := v.Type()
+	if len(args) < t.NumIn()-1 || (!t.IsVariadic() && len(args) != t.NumIn()) {
+		return nil, fmt.Errorf("SafeCall: %s takes %d arguments, got %d", t, t.NumIn(), len(args))
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		pt := paramType(t, i)
+		if arg == nil {
+			in[i] = reflect.Zero(pt)
+			continue
+		}
+		in[i] = reflect.ValueOf(arg)
+		if !in[i].Type().AssignableTo(pt) {
+			return nil, fmt.Errorf("SafeCall: argument %d has type %s, want %s", i, in[i].Type(), pt)
+		}
+	}
+	out, err := safeCall(v, in, false)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, len(out))
+	for i, r := range out {
+		results[i] = r.Interface()
+	}
+	return results, nil
+}
+
+// paramType returns the type of the i-th argument of a call to t.
+func paramType(t reflect.Type, i int) reflect.Type {
+	if t.IsVariadic() && i >= t.NumIn()-1 {
+		return t.In(t.NumIn() - 1).Elem()
+	}
+	return t.In(i)
+}
+
+// safeCall calls fn with faults turned into a *FaultError.
+func safeCall(fn reflect.Value, in []reflect.Value, slice bool) (out []reflect.Value, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			fault := asFault(r)
+			if fault == nil {
+				panic(r)
+			}
+			if f := runtime.FuncForPC(fn.Pointer()); f != nil {
+				fault.Symbol = f.Name()
+			}
+			out, err = nil, fault
+		}
+	}()
+	if slice {
+		return fn.CallSlice(in), nil
+	}
+	return fn.Call(in), nil
+}
+
+// asFault returns a *FaultError for a recovered panic value caused by a
+// memory fault, or nil.
+func asFault(r interface{}) *FaultError {
+	err, ok := r.(runtime.Error)
+	if !ok {
+		return nil
+	}
+	// Since Go 1.17 fault panics carry the address; before, only the
+	// message tells them apart.
+	if addr, ok := r.(interface{ Addr() uintptr }); ok {
+		return &FaultError{Addr: addr.Addr(), Err: err}
+	}
+	if msg := err.Error(); strings.Contains(msg, "fault") || strings.Contains(msg, "invalid memory address") {
+		return &FaultError{Err: err}
+	}
+	return nil
+}
+
+// guard sets outFuncPtr to a function calling codePtr through safeCall.
+func guard(outFuncPtr interface{}, codePtr uintptr) {
+	fnType := reflect.TypeOf(outFuncPtr).Elem()
+	inner := reflect.New(fnType)
+	CreateFuncForCodePtr(inner.Interface(), codePtr)
+	fn := inner.Elem()
+	returnsError := fnType.NumOut() > 0 && fnType.Out(fnType.NumOut()-1) == errorType
+
+	wrapper := reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+		out, err := safeCall(fn, in, fnType.IsVariadic())
+		if err == nil {
+			return out
+		}
+		if !returnsError {
+			panic(err)
+		}
+		out = make([]reflect.Value, fnType.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(fnType.Out(i))
+		}
+		out[len(out)-1] = reflect.ValueOf(&err).Elem()
+		return out
+	})
+	reflect.ValueOf(outFuncPtr).Elem().Set(wrapper)
+}
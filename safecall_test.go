@@ -0,0 +1,78 @@
+package forceexport
+
+import (
+	"errors"
+	"testing"
+)
+
+const faultAddr = 0xdead0000
+
+//go:noinline
+func readPoint(p *stackmapPoint) (int, error) {
+	return p.x, nil
+}
+
+func TestSafeCall(t *testing.T) {
+	stackmapMix(1, "a", &stackmapPoint{}, 1)
+	name := "github.com/szmcdull/go-forceexport.stackmapMix"
+	var wrong func(int, string, uintptr, float64) int
+	if err := GetFunc(&wrong, name); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := SafeCall(wrong, 1, "a", uintptr(faultAddr), nil)
+	var fault *FaultError
+	if !errors.As(err, &fault) || results != nil {
+		t.Fatalf("Expected a *FaultError, got %v, %v.", results, err)
+	}
+	if fault.Symbol != name {
+		t.Errorf("Expected the fault to name %s, got %s.", name, fault.Symbol)
+	}
+	if fault.Addr != 0 && (fault.Addr < faultAddr || fault.Addr >= faultAddr+16) {
+		t.Errorf("Unexpected fault address %#x.", fault.Addr)
+	}
+
+	results, err = SafeCall(addOne, 2)
+	if err != nil || len(results) != 1 || results[0] != 3 {
+		t.Errorf("Expected [3], got %v, %v.", results, err)
+	}
+	if _, err := SafeCall(addOne); err == nil {
+		t.Error("Expected an error for a missing argument.")
+	}
+	if _, err := SafeCall(addOne, "2"); err == nil {
+		t.Error("Expected an error for a mistyped argument.")
+	}
+	defer func() {
+		if r := recover(); r != "not a fault" {
+			t.Errorf("Expected other panics to propagate, got %v.", r)
+		}
+	}()
+	SafeCall(func() { panic("not a fault") })
+}
+
+func TestGuarded(t *testing.T) {
+	readPoint(&stackmapPoint{})
+	name := "github.com/szmcdull/go-forceexport.readPoint"
+
+	var read func(uintptr) (int, error)
+	if err := GetFunc(&read, name, Guarded()); err != nil {
+		t.Fatal(err)
+	}
+	var fault *FaultError
+	if n, err := read(faultAddr); n != 0 || !errors.As(err, &fault) {
+		t.Errorf("Expected a *FaultError, got %d, %v.", n, err)
+	}
+
+	var mix func(int, string, uintptr, float64) int
+	if err := GetFunc(&mix, "github.com/szmcdull/go-forceexport.stackmapMix", Guarded()); err != nil {
+		t.Fatal(err)
+	}
+	func() {
+		defer func() {
+			if _, ok := recover().(*FaultError); !ok {
+				t.Error("Expected a panic with a *FaultError.")
+			}
+		}()
+		mix(1, "a", faultAddr, 1)
+	}()
+}
@@ -0,0 +1,38 @@
+package forceexport
+
+import (
+	"runtime/debug"
+	"unsafe"
+)
+
+// readWithRecover copies the memory at addr into buf after checking it with
+// IsAddrReadable, turning a fault during the copy into errFault. This relies
+// on debug.SetPanicOnFault and is the fallback where the system offers no
+// fault-free way of reading memory.
+func readWithRecover(addr uintptr, buf []byte) (err error) {
+	if !IsAddrReadable(addr, len(buf)) {
+		return errFault
+	}
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if recover() != nil {
+			err = errFault
+		}
+	}()
+	copy(buf, (*[1 << 30]byte)(unsafe.Pointer(addr))[:len(buf):len(buf)])
+	return nil
+}
+
+// safeReadUintptr reads a word with SafeRead.
+func safeReadUintptr(addr uintptr) (uintptr, bool) {
+	var v uintptr
+	err := SafeRead(addr, (*[unsafe.Sizeof(v)]byte)(unsafe.Pointer(&v))[:])
+	return v, err == nil
+}
+
+// safeReadUint8 reads a byte with SafeRead.
+func safeReadUint8(addr uintptr) (uint8, bool) {
+	var v [1]byte
+	err := SafeRead(addr, v[:])
+	return v[0], err == nil
+}
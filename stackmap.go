@@ -0,0 +1,83 @@
+package forceexport
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Indexes of the funcdata of a _func; see runtime/funcdata.h.
+const (
+	funcdataArgsPointerMaps = 0
+)
+
+// stackmap is the runtime's encoding of the pointer maps of one function: n
+// bitmaps of nbit bits each, one per stack map index (safe point).
+type stackmap struct {
+	n        int32
+	nbit     int32
+	bytedata [1]byte
+}
+
+// PointerMap has one entry per pointer-sized word of a stack area, true if the
+// word holds a pointer.
+type PointerMap []bool
+
+// String returns the map as a string of 1s (pointer) and 0s (scalar).
+func (me PointerMap) String() string {
+	b := make([]byte, len(me))
+	for i, ptr := range me {
+		b[i] = '0'
+		if ptr {
+			b[i] = '1'
+		}
+	}
+	return string(b)
+}
+
+// ArgPointerMap returns which words of the named function's argument area
+// hold pointers, as recorded by the compiler for the garbage collector. The
+// compiler only marks arguments that are live, so the map is the union over
+// all safe points of the function; an unused pointer argument may not show.
+// The argument area is the one described by FuncInfo.Args: with the register
+// ABI it holds the spill slots of the register arguments, without it all
+// arguments followed by the results.
+func ArgPointerMap(name string) (PointerMap, error) {
+	info, err := FindFuncInfo(name)
+	if err != nil {
+		return nil, err
+	}
+	return info.ArgPointerMap()
+}
+
+// ArgPointerMap is like the package-level ArgPointerMap for an already
+// resolved function.
+func (me *FuncInfo) ArgPointerMap() (PointerMap, error) {
+	return me.pointerMap(funcdataArgsPointerMaps)
+}
+
+func (me *FuncInfo) pointerMap(index uint8) (PointerMap, error) {
+	module, err := me.pcModule()
+	if err != nil {
+		return nil, err
+	}
+	p := module.GetFuncData(me.raw, index)
+	if p == nil {
+		return nil, fmt.Errorf("%s has no pointer map", me.Name)
+	}
+	stkmap := (*stackmap)(p)
+	if stkmap.n < 0 || stkmap.nbit < 0 {
+		return nil, fmt.Errorf("%s has a corrupt pointer map", me.Name)
+	}
+	bitmap := make(PointerMap, stkmap.nbit)
+	bytes := uintptr(stkmap.nbit+7) / 8
+	for i := uintptr(0); i < uintptr(stkmap.n); i++ {
+		data := unsafe.Pointer(uintptr(unsafe.Pointer(&stkmap.bytedata)) + i*bytes)
+		for bit := range bitmap {
+			b := *(*byte)(unsafe.Pointer(uintptr(data) + uintptr(bit/8)))
+			if b&(1<<(uint(bit)%8)) != 0 {
+				bitmap[bit] = true
+			}
+		}
+	}
+	return bitmap, nil
+}
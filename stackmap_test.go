@@ -0,0 +1,82 @@
+package forceexport
+
+import (
+	"reflect"
+	"testing"
+)
+
+type stackmapPoint struct{ x, y int }
+
+//go:noinline
+func stackmapMix(n int, s string, p *stackmapPoint, f float64) int {
+	p.y = n + len(s) + int(f)
+	return p.x
+}
+
+//go:noinline
+func stackmapIface(n int, v interface{}) int {
+	return n + len(v.(string))
+}
+
+func TestArgPointerMap(t *testing.T) {
+	stackmapMix(1, "a", &stackmapPoint{}, 1)
+	name := "github.com/szmcdull/go-forceexport.stackmapMix"
+	m, err := ArgPointerMap(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// n, s.ptr, s.len, p: the string data and p are pointers.
+	if len(m) < 4 || m.String()[:4] != "0101" {
+		t.Errorf("Unexpected pointer map %s.", m)
+	}
+	if expected := argPointerMap(reflect.TypeOf(stackmapMix)); expected.String() != "0101" {
+		t.Errorf("Unexpected computed pointer map %s.", expected)
+	}
+
+	// n, v.type, v.data: only the data word of an interface is a pointer.
+	stackmapIface(1, "a")
+	m, err = ArgPointerMap("github.com/szmcdull/go-forceexport.stackmapIface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) < 3 || m.String()[:3] != "001" {
+		t.Errorf("Unexpected pointer map %s.", m)
+	}
+	if expected := argPointerMap(reflect.TypeOf(stackmapIface)); expected.String() != "001" {
+		t.Errorf("Unexpected computed pointer map %s.", expected)
+	}
+
+	if _, err := ArgPointerMap("invalidpackage.invalidfunction"); err == nil {
+		t.Error("Expected an error for an invalid function.")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	stackmapMix(1, "a", &stackmapPoint{}, 1)
+	name := "github.com/szmcdull/go-forceexport.stackmapMix"
+
+	var mix func(int, string, *stackmapPoint, float64) int
+	if err := GetFunc(&mix, name, Verify()); err != nil {
+		t.Fatal(err)
+	}
+	if mix(1, "a", &stackmapPoint{x: 5}, 1) != 5 {
+		t.Error("Expected the verified function to work.")
+	}
+
+	var wrong func(int, string, uintptr, float64) int
+	if err := GetFunc(&wrong, name, Verify()); err == nil {
+		t.Error("Expected an error for a pointer declared as uintptr.")
+	}
+	if wrong != nil {
+		t.Error("Expected the output to be left unset.")
+	}
+	// Without verification the mismatch goes unnoticed.
+	if err := GetFunc(&wrong, name); err != nil {
+		t.Error(err)
+	}
+
+	var addOneFunc func(int) int
+	if err := GetFunc(&addOneFunc, "github.com/szmcdull/go-forceexport.addOne", Verify()); err != nil {
+		t.Error(err)
+	}
+}
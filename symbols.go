@@ -0,0 +1,87 @@
+package forceexport
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// The runtime's tables only describe functions, and name all instantiations of
+// a generic function alike (pkg.F[...]). Data symbols, such as the
+// dictionaries of generic instantiations, and the full names of instantiated
+// functions are only recorded in the symbol table of the executable file,
+// which is read once on first use. Only ELF executables are supported.
+var exeSymbols struct {
+	once    sync.Once
+	symbols map[string]uint64
+	text    uint64 // link-time address of the text section
+	err     error
+}
+
+func loadExeSymbols() {
+	switch runtime.GOOS {
+	case "windows", "darwin", "ios", "aix", "plan9", "js", "wasip1":
+		exeSymbols.err = fmt.Errorf("symbol table lookup unsupported on %s: only ELF executables are read", runtime.GOOS)
+		return
+	}
+	path, err := os.Executable()
+	if err != nil {
+		exeSymbols.err = err
+		return
+	}
+	f, err := elf.Open(path)
+	if err != nil {
+		exeSymbols.err = fmt.Errorf("cannot read the symbol table of %s: %v", path, err)
+		return
+	}
+	defer f.Close()
+
+	text := f.Section(".text")
+	if text == nil {
+		exeSymbols.err = fmt.Errorf("%s has no .text section", path)
+		return
+	}
+	syms, err := f.Symbols()
+	if err != nil {
+		exeSymbols.err = fmt.Errorf("cannot read the symbol table of %s (stripped?): %v", path, err)
+		return
+	}
+	exeSymbols.text = text.Addr
+	exeSymbols.symbols = make(map[string]uint64, len(syms))
+	for _, s := range syms {
+		exeSymbols.symbols[s.Name] = s.Value
+	}
+}
+
+// findExeSymbol returns the run-time address of a symbol of the main
+// executable, adjusted for where the executable was loaded (PIE).
+func findExeSymbol(name string) (uintptr, error) {
+	exeSymbols.once.Do(loadExeSymbols)
+	if exeSymbols.err != nil {
+		return 0, exeSymbols.err
+	}
+	value, ok := exeSymbols.symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("Invalid symbol name: %s", name)
+	}
+	modules, err := Modules()
+	if err != nil {
+		return 0, err
+	}
+	return uintptr(value) + modules[0].Text - uintptr(exeSymbols.text), nil
+}
+
+// findExeFunc is like findExeSymbol, but also checks that the address is the
+// entry of a function known to the runtime.
+func findExeFunc(name string) (uintptr, error) {
+	pc, err := findExeSymbol(name)
+	if err != nil {
+		return 0, err
+	}
+	if !IsFuncEntry(pc) {
+		return 0, fmt.Errorf("symbol %s at %#x is not a function entry", name, pc)
+	}
+	return pc, nil
+}
@@ -0,0 +1,66 @@
+//go:build go1.18
+// +build go1.18
+
+// Command abi exercises the detection of ABI0 assembly functions; TestABI0
+// runs it because test binaries are built without a symbol table. With the
+// argument "stripped" it expects to be built without one.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/szmcdull/go-forceexport"
+)
+
+func main() {
+	// Keep the assembly function in the binary.
+	if strings.IndexByte("abc", 'c') != 2 {
+		fail("strings.IndexByte should work properly")
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stripped" {
+		stripped()
+		return
+	}
+
+	// IndexByteString is written in assembly using ABI0, and the Go code
+	// calling it is inlined into strings.IndexByte rather than going
+	// through an ABIInternal wrapper.
+	var indexByteString func(string, byte) int
+	if err := forceexport.GetFunc(&indexByteString, "internal/bytealg.IndexByteString"); err == nil {
+		fail("expected an error for an ABI0 assembly function")
+	}
+
+	// memmove is written in assembly but declared ABIInternal.
+	var memmove func(to, from unsafe.Pointer, n uintptr)
+	if err := forceexport.GetFunc(&memmove, "runtime.memmove"); err != nil {
+		fail(err)
+	}
+	src, dst := []byte("abc"), make([]byte, 3)
+	memmove(unsafe.Pointer(&dst[0]), unsafe.Pointer(&src[0]), 3)
+	if string(dst) != "abc" {
+		fail("expected memmove to copy abc")
+	}
+	fmt.Println("ok")
+}
+
+// stripped checks that the ABI of an assembly function is not guessed
+// without a symbol table unless asked to.
+func stripped() {
+	var memmove func(to, from unsafe.Pointer, n uintptr)
+	if err := forceexport.GetFunc(&memmove, "runtime.memmove"); err == nil {
+		fail("expected an error for an assembly function of unknown ABI")
+	}
+	forceexport.SetConfig(forceexport.Config{AssumeABIInternal: true})
+	if err := forceexport.GetFunc(&memmove, "runtime.memmove"); err != nil {
+		fail(err)
+	}
+	fmt.Println("ok")
+}
+
+func fail(v interface{}) {
+	fmt.Println(v)
+	os.Exit(1)
+}
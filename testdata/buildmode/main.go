@@ -0,0 +1,61 @@
+// Command buildmode checks discovery and lookup when built as a PIE
+// executable, or as a C shared library or archive driven by harness.c.
+// FORCEEXPORT_STRATEGY picks the discovery strategy to exercise.
+package main
+
+import "C"
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/szmcdull/go-forceexport"
+)
+
+var strategies = map[string]forceexport.Strategy{
+	"":     forceexport.StrategyAll,
+	"asm":  forceexport.StrategyAsm,
+	"scan": forceexport.StrategyScan,
+}
+
+//export ForceexportCheck
+func ForceexportCheck() C.int {
+	if err := check(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func check() error {
+	strategy, ok := strategies[os.Getenv("FORCEEXPORT_STRATEGY")]
+	if !ok {
+		return fmt.Errorf("unknown strategy %q", os.Getenv("FORCEEXPORT_STRATEGY"))
+	}
+	forceexport.SetConfig(forceexport.Config{Strategies: strategy})
+
+	var double func(int) int
+	if err := forceexport.GetFunc(&double, "main.double"); err != nil {
+		return err
+	}
+	if reflect.ValueOf(double).Pointer() != reflect.ValueOf(doubleFunc).Pointer() || double(21) != 42 {
+		return fmt.Errorf("main.double resolved to the wrong function")
+	}
+	return nil
+}
+
+// doubleFunc references double so that the linker keeps it.
+var doubleFunc = double
+
+//go:noinline
+func double(x int) int {
+	return 2 * x
+}
+
+func main() {
+	if ForceexportCheck() != 0 {
+		os.Exit(1)
+	}
+	fmt.Println("ok")
+}
@@ -0,0 +1,77 @@
+//go:build go1.21
+// +build go1.21
+
+// Command generic exercises GetGenericFunc; TestGetGenericFunc runs it
+// because test binaries are built without a symbol table.
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/szmcdull/go-forceexport"
+)
+
+type point struct {
+	X, y int
+}
+
+//go:noinline
+func sumOf[E int | float64](s []E) E {
+	var sum E
+	for _, v := range s {
+		sum += v
+	}
+	return sum
+}
+
+//go:noinline
+func lastOf[E any](s ...E) E {
+	return s[len(s)-1]
+}
+
+// Taking sumOf[float64] as a value makes the compiler emit a plain
+// instantiation; the other instantiations only exist as shape functions.
+var sumOfFloat64 = sumOf[float64]
+
+func main() {
+	if sumOf([]int{1, 2}) != 3 || sumOfFloat64([]float64{1}) != 1 || lastOf(point{}, point{X: 1}).X != 1 {
+		fail("generic functions should work properly")
+	}
+
+	var sumInts func([]int) int
+	if err := forceexport.GetGenericFunc(&sumInts, "main.sumOf", reflect.TypeOf(0)); err != nil {
+		fail(err)
+	}
+	if sumInts([]int{1, 2, 3}) != 6 {
+		fail("expected sumInts to add up 1, 2 and 3")
+	}
+
+	var sumFloats func([]float64) float64
+	if err := forceexport.GetGenericFunc(&sumFloats, "main.sumOf", reflect.TypeOf(0.0)); err != nil {
+		fail(err)
+	}
+	if sumFloats([]float64{0.5, 0.25}) != 0.75 {
+		fail("expected sumFloats to add up 0.5 and 0.25")
+	}
+
+	var lastPoint func(...point) point
+	if err := forceexport.GetGenericFunc(&lastPoint, "main.lastOf", reflect.TypeOf(point{})); err != nil {
+		fail(err)
+	}
+	if p := lastPoint(point{X: 1}, point{X: 2, y: 3}); p.X != 2 || p.y != 3 {
+		fail(fmt.Sprintf("expected the last point, got %+v", p))
+	}
+
+	var sumStrings func([]string) string
+	if err := forceexport.GetGenericFunc(&sumStrings, "main.sumOf", reflect.TypeOf("")); err == nil {
+		fail("expected an error for a missing instantiation")
+	}
+	fmt.Println("ok")
+}
+
+func fail(v interface{}) {
+	fmt.Println(v)
+	os.Exit(1)
+}
@@ -0,0 +1,13 @@
+// harness calls into a Go library built with -buildmode=c-shared or
+// -buildmode=c-archive from testdata/buildmode.
+#include <stdio.h>
+
+extern int ForceexportCheck(void);
+
+int main(void) {
+	if (ForceexportCheck() != 0) {
+		return 1;
+	}
+	puts("ok");
+	return 0;
+}
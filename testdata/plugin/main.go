@@ -0,0 +1,13 @@
+// Package main is built with -buildmode=plugin by TestPluginModules.
+package main
+
+// Note that we need to disable inlining here, or else the function won't be
+// compiled into the plugin.
+//
+//go:noinline
+func addTwo(x int) int {
+	return x + 2
+}
+
+// AddTwo keeps addTwo reachable.
+var AddTwo = addTwo
@@ -0,0 +1,74 @@
+//go:build go1.18
+// +build go1.18
+
+package forceexport
+
+import "runtime"
+
+// textAddr returns the pc of an offset from the start of the module's text,
+// as runtime.moduledata.textAddr does. The linker splits the text of very large
+// binaries into several sections on some architectures (e.g. ppc64 and arm64)
+// and textsectmap records where each one was put. Offsets beyond the text
+// give 0, where the runtime would throw.
+func (me *newModuleWrapper) textAddr(off32 uint32) uintptr {
+	off := uintptr(off32)
+	res := me.text + off
+	if len(me.textsectmap) > 1 {
+		for i, sect := range me.textsectmap {
+			// The last section includes its end, which the function table
+			// uses as the end of the text.
+			if off >= sect.vaddr && off < sect.end || (i == len(me.textsectmap)-1 && off == sect.end) {
+				res = sect.baseaddr + off - sect.vaddr
+				break
+			}
+		}
+		// On wasm functions do not live in the address space of the data.
+		if res > me.etext && runtime.GOARCH != "wasm" {
+			return 0
+		}
+	}
+	return res
+}
+
+// textOff is the inverse of textAddr: it returns the offset of pc from the
+// start of the module's text, or false if pc is in none of its sections.
+func (me *newModuleWrapper) textOff(pc uintptr) (uint32, bool) {
+	off := pc - me.text
+	if len(me.textsectmap) > 1 {
+		for i, sect := range me.textsectmap {
+			if sect.baseaddr > pc {
+				return 0, false
+			}
+			end := sect.baseaddr + (sect.end - sect.vaddr)
+			if i == len(me.textsectmap)-1 {
+				end++
+			}
+			if pc < end {
+				off = pc - sect.baseaddr + sect.vaddr
+				break
+			}
+		}
+	}
+	return uint32(off), true
+}
+
+// FindFunc returns the index in the function table of the function
+// containing pc, or -1, as runtime.findfunc does: findfunctab narrows the
+// search down to a few entries.
+func (me *newModuleWrapper) FindFunc(pc uintptr) int {
+	if pc < me.minpc || pc >= me.maxpc || me.findfunctab == 0 {
+		return -1
+	}
+	pcOff, ok := me.textOff(pc)
+	if !ok {
+		return -1
+	}
+	idx := findFuncBucket(me.findfunctab, uintptr(pcOff)+me.text-me.minpc)
+	for idx+1 < len(me.ftab) && me.ftab[idx+1].entryoff <= pcOff {
+		idx++
+	}
+	if idx+1 >= len(me.ftab) {
+		return -1
+	}
+	return idx
+}
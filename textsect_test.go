@@ -0,0 +1,51 @@
+//go:build go1.18
+// +build go1.18
+
+package forceexport
+
+import "testing"
+
+func TestGetEntry(t *testing.T) {
+	module := getModuleWrapper()
+	if module == nil {
+		t.Skip("moduledata not found")
+	}
+	ftab := module.GetFtab()
+	for _, ft := range ftab[:len(ftab)-1] {
+		if f := module.GetFunc(ft); module.GetEntry(ft) != f.Entry() {
+			t.Fatalf("Expected %s at %#x, got %#x.", f.Name(), f.Entry(), module.GetEntry(ft))
+		}
+	}
+	// The last entry marks the end of the text.
+	if end, maxpc := module.GetEntry(ftab[len(ftab)-1]), module.GetInfo().MaxPC; end != maxpc {
+		t.Errorf("Expected the function table to end at %#x, got %#x.", maxpc, end)
+	}
+}
+
+func TestTextAddr(t *testing.T) {
+	// Two sections of 0x100 bytes, the second one moved to 0x9000.
+	m := &newModuleWrapper{text: 0x1000, etext: 0x9100}
+	m.textsectmap = []textsect{
+		{vaddr: 0, end: 0x100, baseaddr: 0x1000},
+		{vaddr: 0x100, end: 0x200, baseaddr: 0x9000},
+	}
+	for _, c := range []struct {
+		off  uint32
+		want uintptr
+	}{
+		{0, 0x1000},
+		{0xff, 0x10ff},
+		{0x100, 0x9000},
+		{0x200, 0x9100}, // end of the last section
+		{0x9000, 0},     // beyond the text
+	} {
+		if got := m.textAddr(c.off); got != c.want {
+			t.Errorf("textAddr(%#x) = %#x, want %#x.", c.off, got, c.want)
+		}
+	}
+
+	m.textsectmap = m.textsectmap[:1]
+	if got := m.textAddr(0x150); got != 0x1150 {
+		t.Errorf("Expected a single section to be contiguous, got %#x.", got)
+	}
+}
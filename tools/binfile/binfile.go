@@ -0,0 +1,239 @@
+// Package binfile reads the function table of a Go binary without running it,
+// so that the names a program passes to forceexport.GetFunc can be checked
+// against a build for any platform: ELF (Linux and most Unixes), PE (Windows)
+// and Mach-O (macOS) binaries are supported, whatever the host.
+package binfile
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/szmcdull/go-forceexport/internal/pclntab"
+	"github.com/szmcdull/go-forceexport/internal/symname"
+)
+
+// ErrNoPCLNTab is returned for files without a Go function table.
+var ErrNoPCLNTab = errors.New("no Go function table found")
+
+// File is the function table of a Go binary.
+type File struct {
+	Format string   // "elf", "pe" or "macho"
+	Funcs  []string // names of all functions, sorted
+
+	// located tells how the table was found: "section", "symbol" or
+	// "scan".
+	located string
+	funcs   map[string]bool
+}
+
+// Open reads the function table of the binary at path. The table is taken
+// from its section (ELF, Mach-O) or the runtime.pclntab symbol (PE) and, if
+// the binary was stripped of those, found by scanning for its header.
+func Open(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Has reports whether the binary contains the function with the given name,
+// in the form accepted by GetFunc.
+func (me *File) Has(name string) bool {
+	return me.funcs[symname.Mangle(name)]
+}
+
+// Missing returns the names the binary does not contain.
+func (me *File) Missing(names []string) []string {
+	var missing []string
+	for _, name := range names {
+		if !me.Has(name) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// image is what the format-specific readers extract from a binary.
+type image struct {
+	format    string
+	textStart uint64
+	pclntab   []byte // nil if not found through the file's metadata
+	located   string
+}
+
+func parse(data []byte) (*File, error) {
+	var img image
+	var err error
+	switch {
+	case bytes.HasPrefix(data, []byte(elf.ELFMAG)):
+		img, err = readELF(data)
+	case bytes.HasPrefix(data, []byte("MZ")):
+		img, err = readPE(data)
+	case isMachO(data):
+		img, err = readMachO(data)
+	default:
+		return nil, errors.New("not an ELF, PE or Mach-O file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var table *gosym.Table
+	if img.pclntab != nil {
+		table, err = pclntab.NewTable(img.pclntab, img.textStart)
+	}
+	if table == nil {
+		img.located = "scan"
+		table, err = scanTable(data, img.textStart)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{Format: img.format, located: img.located, funcs: make(map[string]bool, len(table.Funcs))}
+	for _, fn := range table.Funcs {
+		if !f.funcs[fn.Name] {
+			f.funcs[fn.Name] = true
+			f.Funcs = append(f.Funcs, fn.Name)
+		}
+	}
+	sort.Strings(f.Funcs)
+	return f, nil
+}
+
+func readELF(data []byte) (image, error) {
+	ef, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return image{}, err
+	}
+	img := image{format: "elf"}
+	if s := ef.Section(".text"); s != nil {
+		img.textStart = s.Addr
+	}
+	for _, name := range []string{".gopclntab", ".data.rel.ro.gopclntab"} {
+		if s := ef.Section(name); s != nil && s.Type != elf.SHT_NOBITS {
+			img.pclntab, err = s.Data()
+			img.located = "section"
+			break
+		}
+	}
+	return img, err
+}
+
+func isMachO(data []byte) bool {
+	if len(data) < 4 {
+		return false
+	}
+	switch magic := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24; magic {
+	case macho.Magic32, macho.Magic64, macho.MagicFat:
+		return true
+	}
+	switch magic := uint32(data[3]) | uint32(data[2])<<8 | uint32(data[1])<<16 | uint32(data[0])<<24; magic {
+	case macho.Magic32, macho.Magic64, macho.MagicFat:
+		return true
+	}
+	return false
+}
+
+func readMachO(data []byte) (image, error) {
+	mf, err := macho.NewFile(bytes.NewReader(data))
+	if err != nil {
+		if ff, ferr := macho.NewFatFile(bytes.NewReader(data)); ferr == nil && len(ff.Arches) > 0 {
+			mf, err = ff.Arches[0].File, nil
+		}
+	}
+	if err != nil {
+		return image{}, err
+	}
+	img := image{format: "macho"}
+	if s := mf.Section("__text"); s != nil {
+		img.textStart = s.Addr
+	}
+	if s := mf.Section("__gopclntab"); s != nil {
+		img.pclntab, err = s.Data()
+		img.located = "section"
+	}
+	return img, err
+}
+
+func readPE(data []byte) (image, error) {
+	pf, err := pe.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return image{}, err
+	}
+	img := image{format: "pe"}
+	var imageBase uint64
+	switch oh := pf.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase = uint64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		imageBase = oh.ImageBase
+	}
+	if s := pf.Section(".text"); s != nil {
+		img.textStart = imageBase + uint64(s.VirtualAddress)
+	}
+
+	// PE has no section of its own for the table; the linker's symbols
+	// bracket it unless the binary was built with -ldflags=-s.
+	var start, end *pe.Symbol
+	for _, sym := range pf.Symbols {
+		switch sym.Name {
+		case "runtime.pclntab":
+			start = sym
+		case "runtime.epclntab":
+			end = sym
+		}
+	}
+	if start == nil || end == nil || start.SectionNumber != end.SectionNumber ||
+		start.SectionNumber < 1 || int(start.SectionNumber) > len(pf.Sections) || start.Value > end.Value {
+		return img, nil
+	}
+	sectData, err := pf.Sections[start.SectionNumber-1].Data()
+	if err != nil {
+		return img, err
+	}
+	if int(end.Value) <= len(sectData) {
+		img.pclntab = sectData[start.Value:end.Value]
+		img.located = "symbol"
+	}
+	return img, nil
+}
+
+// scanTable finds the function table by its header: a magic word, two zero
+// bytes, the instruction size quantum and the pointer size, in either byte
+// order.
+func scanTable(data []byte, textStart uint64) (*gosym.Table, error) {
+	for _, magic := range pclntab.Magics {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			pattern := make([]byte, 6)
+			order.PutUint32(pattern, magic)
+			for off := 0; ; off++ {
+				i := bytes.Index(data[off:], pattern)
+				if i < 0 {
+					break
+				}
+				off += i
+				if _, ok := pclntab.ParseHeader(data[off:], order); !ok {
+					continue
+				}
+				if table, err := pclntab.NewTable(data[off:], textStart); err == nil {
+					return table, nil
+				}
+			}
+		}
+	}
+	return nil, ErrNoPCLNTab
+}
@@ -0,0 +1,76 @@
+package binfile
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// buildHello cross-compiles testdata/hello and returns the path of the binary.
+func buildHello(t *testing.T, goos, goarch string, ldflags string) string {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "hello")
+	// Build with the toolchain running the test.
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-o", out, "-ldflags="+ldflags, "./testdata/hello")
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+	return out
+}
+
+func TestOpen(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds binaries")
+	}
+	tests := []struct {
+		goos, goarch, ldflags string
+		format, located       string
+	}{
+		{"linux", "amd64", "", "elf", "section"},
+		{"linux", "amd64", "-s -w", "elf", "section"},
+		{"linux", "s390x", "", "elf", "section"},
+		{"windows", "amd64", "", "pe", "symbol"},
+		{"windows", "amd64", "-s -w", "pe", "scan"},
+		{"windows", "386", "-s -w", "pe", "scan"},
+		{"darwin", "arm64", "", "macho", "section"},
+		{"darwin", "amd64", "-s -w", "macho", "section"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.goos+"-"+test.goarch+test.ldflags, func(t *testing.T) {
+			t.Parallel()
+			f, err := Open(buildHello(t, test.goos, test.goarch, test.ldflags))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Format != test.format {
+				t.Errorf("Format = %q, want %q", f.Format, test.format)
+			}
+			if f.located != test.located {
+				t.Errorf("function table found by %s, want %s", f.located, test.located)
+			}
+			for _, name := range []string{"main.hello", "main.main", "runtime.mallocgc", "fmt.Fprintln"} {
+				if !f.Has(name) {
+					t.Errorf("%s not found", name)
+				}
+			}
+			missing := f.Missing([]string{"main.hello", "main.nothere"})
+			if len(missing) != 1 || missing[0] != "main.nothere" {
+				t.Errorf("Missing = %q, want [main.nothere]", missing)
+			}
+		})
+	}
+}
+
+func TestOpenNotGo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "garbage")
+	if err := os.WriteFile(path, []byte("MZ not really a PE file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open succeeded on a corrupt file")
+	}
+}
@@ -0,0 +1,13 @@
+// Command hello is built for several platforms by the binfile tests.
+package main
+
+import "fmt"
+
+//go:noinline
+func hello() string {
+	return "hello"
+}
+
+func main() {
+	fmt.Println(hello())
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/szmcdull/go-forceexport/tools/srcfunc"
+)
+
+// generator accumulates the declarations of one output file.
+type generator struct {
+	pkgName  string
+	initName string
+
+	imports  map[string]string // import path -> local name
+	names    map[string]bool   // identifiers already taken
+	vars     []genVar
+	warnings []string
+}
+
+type genVar struct {
+	ident string
+	name  string // forceexport name
+	typ   string
+}
+
+func newGenerator(pkgName, initName string) *generator {
+	return &generator{
+		pkgName:  pkgName,
+		initName: initName,
+		imports:  map[string]string{"github.com/szmcdull/go-forceexport": "forceexport"},
+		names:    map[string]bool{initName: true},
+	}
+}
+
+// add declares a variable for f.
+func (me *generator) add(f *srcfunc.Func) {
+	t := &translator{gen: me, name: f.Name}
+	typ := t.signature(f.Sig)
+	me.vars = append(me.vars, genVar{ident: me.ident(f.Name), name: f.Name, typ: typ})
+}
+
+// ident derives an exported Go identifier from a function name, e.g.
+// net/http.(*Transport).getConn becomes HttpTransportGetConn.
+func (me *generator) ident(name string) string {
+	pkgPath, rest, _ := srcfunc.SplitName(name)
+	parts := []string{pkgPath[strings.LastIndex(pkgPath, "/")+1:]}
+	parts = append(parts, strings.FieldsFunc(rest, func(r rune) bool {
+		return r == '.' || r == '(' || r == ')' || r == '*'
+	})...)
+	var b strings.Builder
+	for _, p := range parts {
+		p = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, p)
+		if p == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter(rune(ident[0])) {
+		ident = "F" + ident
+	}
+	base := ident
+	for i := 2; me.names[ident]; i++ {
+		ident = base + strconv.Itoa(i)
+	}
+	me.names[ident] = true
+	return ident
+}
+
+// importName returns the local name for path, adding an import if needed.
+func (me *generator) importName(pkg *types.Package) string {
+	if name, ok := me.imports[pkg.Path()]; ok {
+		return name
+	}
+	name := pkg.Name()
+	taken := func(n string) bool {
+		for _, used := range me.imports {
+			if used == n {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 2; taken(name); i++ {
+		name = pkg.Name() + strconv.Itoa(i)
+	}
+	me.imports[pkg.Path()] = name
+	return name
+}
+
+func (me *generator) warnf(format string, args ...interface{}) {
+	me.warnings = append(me.warnings, fmt.Sprintf(format, args...))
+}
+
+// source renders the output file.
+func (me *generator) source() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by forceexport-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", me.pkgName)
+
+	paths := make([]string, 0, len(me.imports))
+	for path := range me.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	b.WriteString("import (\n")
+	for _, path := range paths {
+		name := me.imports[path]
+		if name == path[strings.LastIndex(path, "/")+1:] {
+			fmt.Fprintf(&b, "%q\n", path)
+		} else {
+			fmt.Fprintf(&b, "%s %q\n", name, path)
+		}
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString("var (\n")
+	for _, v := range me.vars {
+		fmt.Fprintf(&b, "// %s is %s, resolved by %s.\n%s %s\n\n", v.ident, v.name, me.initName, v.ident, v.typ)
+	}
+	b.WriteString(")\n\n")
+
+	fmt.Fprintf(&b, `// %[1]s resolves every function declared in this file with a single pass
+// over the function table. Call it once at startup, before any of them is
+// used. Functions that cannot be found are left nil and listed in the
+// returned error.
+func %[1]s() error {
+	return forceexport.NewResolver().
+`, me.initName)
+	for _, v := range me.vars {
+		fmt.Fprintf(&b, "Add(&%s, %q).\n", v.ident, v.name)
+	}
+	b.WriteString("Resolve()\n}\n")
+
+	return format.Source(b.Bytes())
+}
+
+// translator spells the types of one function's signature in the output
+// package, substituting stand-ins for types the output package cannot name.
+type translator struct {
+	gen  *generator
+	name string
+}
+
+func (me *translator) signature(sig *types.Signature) string {
+	return "func" + me.params(sig.Params(), sig.Variadic()) + me.results(sig.Results())
+}
+
+func (me *translator) params(t *types.Tuple, variadic bool) string {
+	named := true
+	for i := 0; i < t.Len(); i++ {
+		if n := t.At(i).Name(); n == "" || n == "_" {
+			named = false
+		}
+	}
+	parts := make([]string, t.Len())
+	for i := range parts {
+		v := t.At(i)
+		var typ string
+		if variadic && i == t.Len()-1 {
+			typ = "..." + me.typ(v.Type().(*types.Slice).Elem())
+		} else {
+			typ = me.typ(v.Type())
+		}
+		if named {
+			typ = v.Name() + " " + typ
+		}
+		parts[i] = typ
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (me *translator) results(t *types.Tuple) string {
+	switch {
+	case t.Len() == 0:
+		return ""
+	case t.Len() == 1 && t.At(0).Name() == "":
+		return " " + me.typ(t.At(0).Type())
+	}
+	return " " + me.params(t, false)
+}
+
+func (me *translator) typ(t types.Type) string {
+	switch t := types.Unalias(t).(type) {
+	case *types.Basic:
+		if t.Kind() == types.UnsafePointer {
+			return me.unsafePointer()
+		}
+		return t.Name()
+	case *types.Named:
+		obj := t.Obj()
+		if obj.Pkg() == nil {
+			return obj.Name() // error
+		}
+		if t.TypeArgs().Len() > 0 {
+			me.gen.warnf("%s: generic type %s replaced with unsafe.Pointer", me.name, t)
+			return me.unsafePointer()
+		}
+		if !obj.Exported() {
+			return me.standIn(t)
+		}
+		return me.gen.importName(obj.Pkg()) + "." + obj.Name()
+	case *types.Pointer:
+		if n, ok := types.Unalias(t.Elem()).(*types.Named); ok && !n.Obj().Exported() && n.Obj().Pkg() != nil {
+			me.gen.warnf("%s: *%s replaced with unsafe.Pointer", me.name, n)
+			return me.unsafePointer()
+		}
+		return "*" + me.typ(t.Elem())
+	case *types.Slice:
+		return "[]" + me.typ(t.Elem())
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), me.typ(t.Elem()))
+	case *types.Map:
+		return "map[" + me.typ(t.Key()) + "]" + me.typ(t.Elem())
+	case *types.Chan:
+		switch t.Dir() {
+		case types.SendOnly:
+			return "chan<- " + me.typ(t.Elem())
+		case types.RecvOnly:
+			return "<-chan " + me.typ(t.Elem())
+		}
+		return "chan " + me.typ(t.Elem())
+	case *types.Signature:
+		return me.signature(t)
+	case *types.Struct:
+		fields := make([]string, t.NumFields())
+		for i := range fields {
+			f := t.Field(i)
+			fields[i] = f.Name() + " " + me.typ(f.Type())
+		}
+		return "struct{" + strings.Join(fields, "; ") + "}"
+	case *types.Interface:
+		if t.Empty() {
+			return "interface{}"
+		}
+		me.gen.warnf("%s: interface %s replaced with %s", me.name, t, me.ifaceStandIn())
+		return me.ifaceStandIn()
+	}
+	me.gen.warnf("%s: unsupported type %s replaced with unsafe.Pointer", me.name, t)
+	return me.unsafePointer()
+}
+
+// standIn spells an unexported named type by its underlying type, which has
+// the same size and register assignment. Named types with methods lose them,
+// and types that are only pointers underneath become unsafe.Pointer.
+func (me *translator) standIn(t *types.Named) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if u.Kind() != types.UnsafePointer {
+			me.gen.warnf("%s: unexported type %s replaced with %s", me.name, t, u.Name())
+			return u.Name()
+		}
+	case *types.Pointer, *types.Map, *types.Chan, *types.Signature:
+	case *types.Interface:
+		if u.Empty() {
+			return "interface{}"
+		}
+		me.gen.warnf("%s: unexported interface %s replaced with %s", me.name, t, me.ifaceStandIn())
+		return me.ifaceStandIn()
+	default:
+		me.gen.warnf("%s: unexported type %s replaced with its underlying type", me.name, t)
+		return me.typ(u)
+	}
+	me.gen.warnf("%s: unexported type %s replaced with unsafe.Pointer", me.name, t)
+	return me.unsafePointer()
+}
+
+// ifaceStandIn spells a non-empty interface the caller cannot name. A struct
+// of two pointers is assigned to two integer registers like the interface,
+// while an array of two would be passed on the stack under ABIInternal.
+func (me *translator) ifaceStandIn() string {
+	return "struct{ _, _ " + me.unsafePointer() + " }"
+}
+
+func (me *translator) unsafePointer() string {
+	return me.gen.importName(types.Unsafe) + ".Pointer"
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/szmcdull/go-forceexport/tools/srcfunc"
+)
+
+func TestGenerate(t *testing.T) {
+	names := []string{
+		"time.now",
+		"runtime.nanotime",
+		"strings.(*Builder).grow",
+		"net/http.(*Transport).getConn",
+	}
+	src, warnings, err := generate(srcfunc.NewLoader("."), "forced", "Init", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "forced.go", src, 0); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"TimeNow func() (sec int64, nsec int32, mono int64)",
+		"RuntimeNanotime func() int64",
+		"StringsBuilderGrow func(*strings.Builder, int)",
+		"HttpTransportGetConn func(",
+		`Add(&TimeNow, "time.now").`,
+		"func Init() error {",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source lacks %q:\n%s", want, src)
+		}
+	}
+
+	// getConn takes a *transportRequest and a connectMethod, neither of
+	// which can be named outside net/http.
+	var sawStandIn bool
+	for _, w := range warnings {
+		if strings.HasPrefix(w, "net/http.(*Transport).getConn: ") {
+			sawStandIn = true
+		}
+	}
+	if !sawStandIn {
+		t.Errorf("expected stand-in warnings for getConn, got %q", warnings)
+	}
+}
+
+const runMain = `package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"github.com/szmcdull/go-forceexport/tools/cmd/forceexport-gen/testdata/target"
+)
+
+func main() {
+	if target.Measure == nil {
+		panic("measure is not linked in")
+	}
+	if err := Init(); err != nil {
+		fmt.Println(err)
+		os.Exit(3)
+	}
+	var buf bytes.Buffer
+	n, err := IoCopyBuffer(&buf, strings.NewReader("forced"), nil)
+	words := target.ShapeWords(3)
+	area := TargetMeasure(*(*struct{ _, _ unsafe.Pointer })(unsafe.Pointer(&words)), 2)
+	fmt.Println(n, err, buf.String(), area)
+}
+`
+
+func TestGenerateRuns(t *testing.T) {
+	names := []string{
+		"io.copyBuffer",
+		"github.com/szmcdull/go-forceexport/tools/cmd/forceexport-gen/testdata/target.measure",
+	}
+	src, _, err := generate(srcfunc.NewLoader("."), "main", "Init", names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The program has to live inside this module to import the target.
+	dir, err := os.MkdirTemp(".", "_run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "forced.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(runMain), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	exe := filepath.Join(t.TempDir(), "forced")
+	cmd := exec.Command(filepath.Join(runtime.GOROOT(), "bin", "go"), "build", "-o", exe, "./"+filepath.Base(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated code does not build: %v\n%s\n%s", err, out, src)
+	}
+	out, err := exec.Command(exe).CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 3 {
+		t.Skipf("forceexport does not support %s: %s", runtime.Version(), out)
+	}
+	if err != nil {
+		t.Fatalf("generated code does not run: %v\n%s\n%s", err, out, src)
+	}
+	if got, want := strings.TrimSpace(string(out)), "6 <nil> forced 18"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestGenerateMissing(t *testing.T) {
+	_, _, err := generate(srcfunc.NewLoader("."), "forced", "Init", []string{"time.now", "time.doesNotExist", "time.Sleep.func1"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"time.doesNotExist", "time.Sleep.func1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Command forceexport-gen generates typed variables for unexported functions.
+//
+// Given names in the form accepted by forceexport.GetFunc, it reads the
+// declaring packages' sources (GOROOT or the module cache) and writes a Go
+// file with one package-level function variable per name and a single Init
+// function that resolves them all:
+//
+//	//go:generate forceexport-gen -o forced.go time.now runtime.nanotime net/http.(*Transport).getConn
+//
+// Unexported types in signatures cannot be named outside their package and
+// are replaced by layout-compatible stand-ins (unsafe.Pointer for pointers,
+// the underlying type otherwise); every substitution is reported on stderr.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/szmcdull/go-forceexport/tools/namelist"
+	"github.com/szmcdull/go-forceexport/tools/srcfunc"
+)
+
+func main() {
+	var (
+		out      = flag.String("o", "", "output file (default stdout)")
+		pkgName  = flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the output file (default $GOPACKAGE or main)")
+		initName = flag.String("init", "Init", "name of the generated resolve function")
+		list     = flag.String("f", "", "read names from `file`, one per line (# starts a comment)")
+		dir      = flag.String("dir", ".", "resolve packages as seen from the module in `dir`")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: forceexport-gen [flags] name...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	names := flag.Args()
+	if *list != "" {
+		more, err := namelist.ReadFile(*list)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		names = append(names, more...)
+	}
+	if len(names) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *pkgName == "" {
+		*pkgName = "main"
+	}
+
+	src, warnings, err := generate(srcfunc.NewLoader(*dir), *pkgName, *initName, names)
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "forceexport-gen: warning: %s\n", w)
+	}
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// generate resolves names with loader and renders the output file.
+func generate(loader *srcfunc.Loader, pkgName, initName string, names []string) ([]byte, []string, error) {
+	gen := newGenerator(pkgName, initName)
+	var errs []string
+	for _, name := range names {
+		f, err := loader.Lookup(name)
+		if err != nil {
+			if errors.Is(err, srcfunc.ErrUnsupported) {
+				err = fmt.Errorf("%s: no source declaration to take the signature from", name)
+			}
+			errs = append(errs, err.Error())
+			continue
+		}
+		gen.add(f)
+	}
+	if len(errs) > 0 {
+		return nil, gen.warnings, errors.New(strings.Join(errs, "\n"))
+	}
+	src, err := gen.source()
+	return src, gen.warnings, err
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "forceexport-gen: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,29 @@
+// Package target declares functions for the forceexport-gen tests to force.
+package target
+
+import "unsafe"
+
+// shape is unexported, so generated code has to pass it through a stand-in.
+type shape interface {
+	area() int
+}
+
+type square int
+
+func (me square) area() int {
+	return int(me * me)
+}
+
+//go:noinline
+func measure(s shape, scale int) int {
+	return s.area() * scale
+}
+
+// Measure keeps measure in the binary.
+var Measure = measure
+
+// ShapeWords returns the two words of a shape holding a square of side n.
+func ShapeWords(n int) [2]unsafe.Pointer {
+	var s shape = square(n)
+	return *(*[2]unsafe.Pointer)(unsafe.Pointer(&s))
+}
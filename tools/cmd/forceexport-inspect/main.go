@@ -0,0 +1,73 @@
+// Command forceexport-inspect checks that a built Go binary contains the
+// functions a program resolves with forceexport, without running it:
+//
+//	forceexport-inspect -f forced.txt app.exe
+//
+// ELF, PE and Mach-O binaries are read on any host, so builds for Windows and
+// macOS can be checked from Linux CI. Missing names are printed on stderr and
+// make the command exit with status 1.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/szmcdull/go-forceexport/tools/binfile"
+	"github.com/szmcdull/go-forceexport/tools/namelist"
+)
+
+func main() {
+	var (
+		list     = flag.String("f", "", "read names from `file`, one per line (# starts a comment)")
+		listAll  = flag.Bool("list", false, "print all function names in the binary")
+		printFmt = flag.Bool("format", false, "print the format of the binary (elf, pe or macho)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: forceexport-inspect [flags] binary [name...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	names := flag.Args()[1:]
+	if *list != "" {
+		more, err := namelist.ReadFile(*list)
+		if err != nil {
+			fatalf("%v", err)
+		}
+		names = append(names, more...)
+	}
+	if len(names) == 0 && !*listAll && !*printFmt {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := binfile.Open(flag.Arg(0))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if *printFmt {
+		fmt.Println(f.Format)
+	}
+	if *listAll {
+		for _, name := range f.Funcs {
+			fmt.Println(name)
+		}
+	}
+	missing := f.Missing(names)
+	for _, name := range missing {
+		fmt.Fprintf(os.Stderr, "%s: %s not found\n", flag.Arg(0), name)
+	}
+	if len(missing) > 0 {
+		os.Exit(1)
+	}
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "forceexport-inspect: "+format+"\n", args...)
+	os.Exit(1)
+}
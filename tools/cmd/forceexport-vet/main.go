@@ -0,0 +1,15 @@
+// Command forceexport-vet checks forceexport.GetFunc calls against the source
+// of the functions they name. Run it on its own or through go vet:
+//
+//	go vet -vettool=$(which forceexport-vet) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/szmcdull/go-forceexport/tools/getfunccheck"
+)
+
+func main() {
+	singlechecker.Main(getfunccheck.Analyzer)
+}
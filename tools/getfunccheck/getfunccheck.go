@@ -0,0 +1,169 @@
+// Package getfunccheck defines an Analyzer that checks calls to
+// forceexport.GetFunc against the source of the function they name.
+//
+// For every call whose name argument is a constant string, the named function
+// is looked up in GOROOT or the module cache. The analyzer reports names that
+// do not exist and output variables whose function type does not match the
+// real signature. Parameters of unexported types cannot be spelled by the
+// caller, so for those any stand-in of the same size (e.g. unsafe.Pointer for
+// a pointer) is accepted.
+package getfunccheck
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+
+	"github.com/szmcdull/go-forceexport/tools/srcfunc"
+)
+
+const doc = `check forceexport.GetFunc calls against the named function's source
+
+The analyzer resolves the constant name passed to forceexport.GetFunc in the
+Go sources of the target package and reports when the function does not exist
+or when the type of the output variable differs from the function's real
+signature.`
+
+// Analyzer is the getfunccheck analyzer.
+var Analyzer = &analysis.Analyzer{
+	Name:     "getfunccheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+const forceexportPath = "github.com/szmcdull/go-forceexport"
+
+var (
+	loadersMu sync.Mutex
+	loaders   = map[string]*srcfunc.Loader{}
+)
+
+// loaderFor returns a shared Loader for the given directory, so that a vet
+// run over many packages type-checks each target package once.
+func loaderFor(dir string) *srcfunc.Loader {
+	loadersMu.Lock()
+	defer loadersMu.Unlock()
+	l := loaders[dir]
+	if l == nil {
+		l = srcfunc.NewLoader(dir)
+		loaders[dir] = l
+	}
+	return l
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != forceexportPath || fn.Name() != "GetFunc" {
+			return
+		}
+		if len(call.Args) < 2 {
+			return
+		}
+		tv, ok := pass.TypesInfo.Types[call.Args[1]]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return
+		}
+		name := constant.StringVal(tv.Value)
+
+		ptr, _ := pass.TypesInfo.TypeOf(call.Args[0]).Underlying().(*types.Pointer)
+		if ptr == nil {
+			pass.ReportRangef(call.Args[0], "forceexport.GetFunc: output argument must be a pointer to a function")
+			return
+		}
+		declared, _ := ptr.Elem().Underlying().(*types.Signature)
+		if declared == nil {
+			pass.ReportRangef(call.Args[0], "forceexport.GetFunc: output argument must be a pointer to a function, not %s", ptr)
+			return
+		}
+
+		dir := filepath.Dir(pass.Fset.Position(call.Pos()).Filename)
+		f, err := loaderFor(dir).Lookup(name)
+		switch {
+		case errors.Is(err, srcfunc.ErrUnsupported):
+			return
+		case errors.Is(err, srcfunc.ErrNotFound):
+			pass.ReportRangef(call.Args[1], "forceexport.GetFunc: function %s does not exist", name)
+			return
+		case err != nil:
+			pass.ReportRangef(call.Args[1], "forceexport.GetFunc: cannot resolve %s: %v", name, err)
+			return
+		}
+
+		if !compatible(pass.TypesSizes, declared, f.Sig) {
+			qual := types.RelativeTo(pass.Pkg)
+			pass.ReportRangef(call.Args[0], "forceexport.GetFunc: %s has type %s, but the output variable is %s",
+				name, types.TypeString(f.Sig, qual), types.TypeString(declared, qual))
+		}
+	})
+	return nil, nil
+}
+
+// compatible reports whether a function value of type declared can safely
+// call a function whose real signature is real, with the sizes of the
+// architecture being checked.
+func compatible(sizes types.Sizes, declared, real *types.Signature) bool {
+	return declared.Variadic() == real.Variadic() &&
+		compatibleTuple(sizes, declared.Params(), real.Params()) &&
+		compatibleTuple(sizes, declared.Results(), real.Results())
+}
+
+func compatibleTuple(sizes types.Sizes, declared, real *types.Tuple) bool {
+	if declared.Len() != real.Len() {
+		return false
+	}
+	for i := 0; i < declared.Len(); i++ {
+		if !compatibleType(sizes, declared.At(i).Type(), real.At(i).Type()) {
+			return false
+		}
+	}
+	return true
+}
+
+func compatibleType(sizes types.Sizes, declared, real types.Type) bool {
+	if types.Identical(declared, real) {
+		return true
+	}
+	// The target package was checked separately from the caller, so the
+	// same named type may be two distinct objects; compare by path instead.
+	qual := func(p *types.Package) string { return p.Path() }
+	if types.TypeString(declared, qual) == types.TypeString(real, qual) {
+		return true
+	}
+	if opaque(real) {
+		return sizes.Sizeof(declared) == sizes.Sizeof(real)
+	}
+	return false
+}
+
+// opaque reports whether t involves an unexported named type that a caller
+// outside its package has to substitute with a stand-in.
+func opaque(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.Named:
+		obj := t.Obj()
+		return obj.Pkg() != nil && !obj.Exported()
+	case *types.Pointer:
+		return opaque(t.Elem())
+	case *types.Slice:
+		return opaque(t.Elem())
+	case *types.Array:
+		return opaque(t.Elem())
+	case *types.Chan:
+		return opaque(t.Elem())
+	case *types.Map:
+		return opaque(t.Key()) || opaque(t.Elem())
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package getfunccheck_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/szmcdull/go-forceexport/tools/getfunccheck"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), getfunccheck.Analyzer, "a")
+}
@@ -0,0 +1,46 @@
+package a
+
+import (
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/szmcdull/go-forceexport"
+)
+
+func ok() {
+	var now func() (int64, int32, int64)
+	forceexport.GetFunc(&now, "time.now")
+
+	var grow func(*strings.Builder, int)
+	forceexport.GetFunc(&grow, "strings.(*Builder).grow")
+
+	var pinSlow func(*sync.Pool) (unsafe.Pointer, int)
+	forceexport.GetFunc(&pinSlow, "sync.(*Pool).pinSlow")
+
+	var closure func()
+	forceexport.GetFunc(&closure, "time.Sleep.func1")
+
+	var verified func() (int64, int32, int64)
+	forceexport.GetFunc(&verified, "time.now", forceexport.Verify(), forceexport.Guarded())
+}
+
+func bad() {
+	var now func() (int64, int32)
+	forceexport.GetFunc(&now, "time.now") // want `time.now has type func\(\) \(sec int64, nsec int32, mono int64\), but the output variable is func\(\) \(int64, int32\)`
+
+	var grow func(strings.Builder, int)
+	forceexport.GetFunc(&grow, "strings.(*Builder).grow") // want `has type`
+
+	var missing func()
+	forceexport.GetFunc(&missing, "time.doesNotExist") // want `function time.doesNotExist does not exist`
+
+	var verified func() int64
+	forceexport.GetFunc(&verified, "time.now", forceexport.Verify()) // want `time.now has type`
+
+	var guarded func()
+	forceexport.GetFunc(&guarded, "time.doesNotExist", forceexport.Guarded()) // want `function time.doesNotExist does not exist`
+
+	var n int
+	forceexport.GetFunc(&n, "time.now") // want `must be a pointer to a function`
+}
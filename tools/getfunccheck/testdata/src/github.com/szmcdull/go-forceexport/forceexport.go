@@ -0,0 +1,11 @@
+package forceexport
+
+type Option func(*getFuncOptions)
+
+type getFuncOptions struct{}
+
+func Verify() Option { return nil }
+
+func Guarded() Option { return nil }
+
+func GetFunc(outFuncPtr interface{}, name string, opts ...Option) error { return nil }
@@ -0,0 +1,15 @@
+module github.com/szmcdull/go-forceexport/tools
+
+go 1.25.0
+
+require golang.org/x/tools v0.47.0
+
+require (
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
+
+require github.com/szmcdull/go-forceexport v0.0.0-00010101000000-000000000000
+
+// The tools share internal packages with the library and are released with it.
+replace github.com/szmcdull/go-forceexport => ../
@@ -0,0 +1,37 @@
+// Package namelist reads the lists of function names the forceexport tools
+// take with -f: one name per line in the form accepted by forceexport.GetFunc,
+// with blank lines ignored and # starting a comment.
+package namelist
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// ReadFile reads the names listed in the file at path.
+func ReadFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads the names listed in r.
+func Parse(r io.Reader) ([]string, error) {
+	var names []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, s.Err()
+}
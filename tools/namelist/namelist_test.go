@@ -0,0 +1,16 @@
+package namelist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	names, err := Parse(strings.NewReader("# forced\ntime.now\n\n  runtime.nanotime # clock\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "time.now" || names[1] != "runtime.nanotime" {
+		t.Errorf("Parse = %q", names)
+	}
+}
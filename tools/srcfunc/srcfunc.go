@@ -0,0 +1,208 @@
+// Package srcfunc resolves the fully-qualified function names accepted by
+// forceexport.GetFunc against the Go source of the package that declares them
+// (GOROOT or the module cache), so tools can see the real signature of a
+// function before it is forced at runtime.
+package srcfunc
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var (
+	// ErrNotFound is returned when the package exists but does not declare
+	// the named function or method.
+	ErrNotFound = errors.New("function not found")
+
+	// ErrUnsupported is returned for names that exist only in the binary and
+	// have no declaration to check against: closures (pkg.F.func1), go/defer
+	// wrappers, generic instantiations and linker-generated symbols.
+	ErrUnsupported = errors.New("name has no source declaration")
+)
+
+// Func is a function or method found in source.
+type Func struct {
+	Name string         // the name as given to GetFunc
+	Pkg  *types.Package // the declaring package
+	Obj  *types.Func
+
+	// Sig is the type of the function value GetFunc produces for Name. For
+	// methods the receiver becomes the first parameter, matching method
+	// expressions such as (*T).M.
+	Sig *types.Signature
+}
+
+// SplitName splits a symbol name such as "net/http.(*Transport).getConn" into
+// the import path ("net/http") and the rest ("(*Transport).getConn"). Dots
+// escaped by the linker as %2e in the last path element are restored.
+func SplitName(name string) (pkgPath, rest string, err error) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", "", fmt.Errorf("invalid function name: %s", name)
+	}
+	dot += slash + 1
+	pkgPath = strings.Replace(name[:dot], "%2e", ".", -1)
+	rest = name[dot+1:]
+	if pkgPath == "" || rest == "" {
+		return "", "", fmt.Errorf("invalid function name: %s", name)
+	}
+	return pkgPath, rest, nil
+}
+
+// Loader type-checks packages from source on demand and caches them. It is
+// safe for concurrent use.
+type Loader struct {
+	// Dir is the directory go list runs in; it decides which module (and so
+	// which versions in the module cache) names are resolved against.
+	Dir string
+
+	mu   sync.Mutex
+	pkgs map[string]*loaded
+}
+
+type loaded struct {
+	once sync.Once
+	pkg  *types.Package
+	err  error
+}
+
+// NewLoader returns a Loader resolving packages as seen from dir.
+func NewLoader(dir string) *Loader {
+	return &Loader{Dir: dir, pkgs: make(map[string]*loaded)}
+}
+
+// Package returns the type-checked package with the given import path. The
+// package is checked from source so that unexported declarations are present.
+func (me *Loader) Package(path string) (*types.Package, error) {
+	me.mu.Lock()
+	l := me.pkgs[path]
+	if l == nil {
+		l = &loaded{}
+		me.pkgs[path] = l
+	}
+	me.mu.Unlock()
+
+	l.once.Do(func() {
+		l.pkg, l.err = me.load(path)
+	})
+	return l.pkg, l.err
+}
+
+func (me *Loader) load(path string) (*types.Package, error) {
+	cfg := &packages.Config{
+		// NeedSyntax forces the package itself to be checked from source;
+		// export data would omit unexported functions.
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedTypesInfo,
+		Dir: me.Dir,
+	}
+	pkgs, err := packages.Load(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("package %s: found %d packages", path, len(pkgs))
+	}
+	pkg := pkgs[0]
+	for _, e := range pkg.Errors {
+		// Type errors are tolerated (the runtime and friends rely on
+		// linkname and assembly), but a package that cannot be listed is
+		// reported.
+		if e.Kind == packages.ListError {
+			return nil, fmt.Errorf("package %s: %v", path, e)
+		}
+	}
+	if pkg.Types == nil || pkg.Types.Scope() == nil {
+		return nil, fmt.Errorf("package %s: no type information", path)
+	}
+	return pkg.Types, nil
+}
+
+// Lookup resolves a GetFunc name to its declaration. It returns an error
+// wrapping ErrNotFound if the package has no such function and one wrapping
+// ErrUnsupported if the name does not correspond to a declaration at all.
+func (me *Loader) Lookup(name string) (*Func, error) {
+	pkgPath, rest, err := SplitName(name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(pkgPath, "go.") || strings.ContainsAny(rest, "[]") {
+		return nil, fmt.Errorf("%s: %w", name, ErrUnsupported)
+	}
+
+	pkg, err := me.Package(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	recv, fn, err := splitMethod(rest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	if recv == "" {
+		obj, _ := pkg.Scope().Lookup(fn).(*types.Func)
+		if obj == nil {
+			return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+		}
+		return &Func{Name: name, Pkg: pkg, Obj: obj, Sig: obj.Type().(*types.Signature)}, nil
+	}
+
+	ptr := strings.HasPrefix(recv, "*")
+	typeName, _ := pkg.Scope().Lookup(strings.TrimPrefix(recv, "*")).(*types.TypeName)
+	if typeName == nil {
+		if !ptr {
+			// pkg.F.func1 and friends look like T.M but name a closure.
+			if _, isFunc := pkg.Scope().Lookup(recv).(*types.Func); isFunc {
+				return nil, fmt.Errorf("%s: %w", name, ErrUnsupported)
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	recvType := typeName.Type()
+	if ptr {
+		recvType = types.NewPointer(recvType)
+	}
+	obj, _, _ := types.LookupFieldOrMethod(recvType, false, pkg, fn)
+	method, _ := obj.(*types.Func)
+	if method == nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrNotFound)
+	}
+	sig := method.Type().(*types.Signature)
+	return &Func{Name: name, Pkg: pkg, Obj: method, Sig: withReceiver(sig, recvType)}, nil
+}
+
+// splitMethod splits "(*T).M", "T.M" or "F" into receiver and function name.
+func splitMethod(rest string) (recv, fn string, err error) {
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ").")
+		if end < 0 {
+			return "", "", fmt.Errorf("malformed receiver")
+		}
+		recv, fn = rest[1:end], rest[end+2:]
+	} else if i := strings.Index(rest, "."); i >= 0 {
+		recv, fn = rest[:i], rest[i+1:]
+	} else {
+		fn = rest
+	}
+	if strings.Contains(fn, ".") {
+		return "", "", ErrUnsupported
+	}
+	return recv, fn, nil
+}
+
+// withReceiver turns a method signature into the equivalent method
+// expression signature.
+func withReceiver(sig *types.Signature, recv types.Type) *types.Signature {
+	params := []*types.Var{types.NewParam(0, nil, "", recv)}
+	for i := 0; i < sig.Params().Len(); i++ {
+		params = append(params, sig.Params().At(i))
+	}
+	return types.NewSignatureType(nil, nil, nil, types.NewTuple(params...), sig.Results(), sig.Variadic())
+}
@@ -0,0 +1,190 @@
+package forceexport
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+const ptrSize = unsafe.Sizeof(uintptr(0))
+
+// Option changes how GetFunc resolves or wraps a function.
+type Option func(*getFuncOptions)
+
+type getFuncOptions struct {
+	verify  bool
+	guarded bool
+}
+
+// Verify makes GetFunc compare the declared function type with the pointer
+// map of the function's arguments and fail if a word the function uses as a
+// pointer is declared as a scalar, e.g. func(int) int for a function taking
+// *T. Pointers declared where the function has scalars cannot be told apart
+// from unused arguments and are not reported.
+func Verify() Option {
+	return func(o *getFuncOptions) {
+		o.verify = true
+	}
+}
+
+// verifyArgs checks fnType against the argument pointer map of info. Assembly
+// functions without a Go declaration have no map and are not checked.
+func verifyArgs(info *FuncInfo, fnType reflect.Type) error {
+	if module := moduleForPC(info.Entry); module == nil || module.GetFuncData(info.raw, funcdataArgsPointerMaps) == nil {
+		return nil
+	}
+	actual, err := info.ArgPointerMap()
+	if err != nil {
+		return err
+	}
+	expected := argPointerMap(fnType)
+	for i, ptr := range actual {
+		if ptr && (i >= len(expected) || !expected[i]) {
+			return fmt.Errorf("%s holds a pointer in argument word %d, but %s does not (pointer maps %s and %s)",
+				info.Name, i, fnType, actual, expected)
+		}
+	}
+	return nil
+}
+
+// abiRegisters returns the number of integer and floating-point registers
+// ABIInternal assigns arguments to.
+func abiRegisters() (ints, floats int) {
+	if !registerABI {
+		return 0, 0
+	}
+	switch runtime.GOARCH {
+	case "amd64":
+		return 9, 15
+	case "ppc64", "ppc64le":
+		return 12, 12
+	}
+	return 16, 16
+}
+
+// argPointerMap lays out the stack argument area of a function of type
+// fnType as ABIInternal does and returns its pointer map: stack-assigned
+// arguments, then stack-assigned results, then the spill slots of the
+// register-assigned arguments. Without the register ABI everything is
+// stack-assigned, which gives the ABI0 layout.
+func argPointerMap(fnType reflect.Type) PointerMap {
+	var m PointerMap
+	var offset uintptr
+	var spill []reflect.Type
+	ints, floats := abiRegisters()
+
+	assign := func(t reflect.Type) bool {
+		a := regAssigner{ints: ints, floats: floats}
+		if a.assign(t) {
+			ints, floats = a.ints, a.floats
+			return true
+		}
+		offset = align(offset, uintptr(t.Align()))
+		m = markPointers(m, t, offset)
+		offset += t.Size()
+		return false
+	}
+	for i := 0; i < fnType.NumIn(); i++ {
+		if assign(fnType.In(i)) {
+			spill = append(spill, fnType.In(i))
+		}
+	}
+	offset = align(offset, ptrSize)
+	ints, floats = abiRegisters()
+	for i := 0; i < fnType.NumOut(); i++ {
+		assign(fnType.Out(i))
+	}
+	offset = align(offset, ptrSize)
+	for _, t := range spill {
+		offset = align(offset, uintptr(t.Align()))
+		m = markPointers(m, t, offset)
+		offset += t.Size()
+	}
+	return m
+}
+
+// regAssigner tracks the registers left while register-assigning a value.
+type regAssigner struct {
+	ints, floats int
+}
+
+// assign reports whether all of t fits in the remaining registers, using
+// them up if it does.
+func (me *regAssigner) assign(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return me.take(0, 1)
+	case reflect.Complex64, reflect.Complex128:
+		return me.take(0, 2)
+	case reflect.Int64, reflect.Uint64:
+		return me.take(int(8/ptrSize), 0)
+	case reflect.String, reflect.Interface:
+		return me.take(2, 0)
+	case reflect.Slice:
+		return me.take(3, 0)
+	case reflect.Array:
+		switch t.Len() {
+		case 0:
+			return true
+		case 1:
+			return me.assign(t.Elem())
+		}
+		return false
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if !me.assign(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return me.take(1, 0)
+}
+
+func (me *regAssigner) take(ints, floats int) bool {
+	if ints > me.ints || floats > me.floats {
+		me.ints, me.floats = -1, -1
+		return false
+	}
+	me.ints -= ints
+	me.floats -= floats
+	return true
+}
+
+// markPointers marks the pointer words of a value of type t at offset.
+func markPointers(m PointerMap, t reflect.Type, offset uintptr) PointerMap {
+	mark := func(off uintptr) PointerMap {
+		word := int(off / ptrSize)
+		for len(m) <= word {
+			m = append(m, false)
+		}
+		m[word] = true
+		return m
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Map, reflect.Chan, reflect.Func,
+		reflect.String, reflect.Slice:
+		m = mark(offset)
+	case reflect.Interface:
+		// The type or itab word is not a pointer to the garbage collector.
+		m = mark(offset + ptrSize)
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			m = markPointers(m, t.Elem(), offset+uintptr(i)*t.Elem().Size())
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			m = markPointers(m, f.Type, offset+f.Offset)
+		}
+	}
+	return m
+}
+
+func align(n, a uintptr) uintptr {
+	if a == 0 {
+		return n
+	}
+	return (n + a - 1) &^ (a - 1)
+}